@@ -0,0 +1,19 @@
+data "utilities_filesystem_usage" "opt" {
+  path = "/opt"
+}
+
+resource "utilities_extract_tar_gz" "release" {
+  destination = "/opt/app"
+  source      = "/tmp/release.tar.gz"
+
+  lifecycle {
+    precondition {
+      condition     = data.utilities_filesystem_usage.opt.available_bytes > 2 * 1024 * 1024 * 1024
+      error_message = "At least 2 GiB must be available on ${data.utilities_filesystem_usage.opt.mount_point}."
+    }
+    precondition {
+      condition     = data.utilities_filesystem_usage.opt.free_inodes > 100000
+      error_message = "Not enough free inodes on ${data.utilities_filesystem_usage.opt.mount_point}."
+    }
+  }
+}
@@ -0,0 +1,7 @@
+terraform {
+  required_providers {
+    utilities = {
+      source = "hashicorp.com/tfstack/utilities"
+    }
+  }
+}
@@ -12,3 +12,18 @@ output "utilities_local_directory" {
     user        = data.utilities_local_directory.example.user,
   }
 }
+
+# Gate a deployment on the contents of a release directory
+data "utilities_local_directory" "release" {
+  path      = "/opt/app/release"
+  recursive = true
+  exclude   = ["**/*.tmp"]
+}
+
+output "release" {
+  value = {
+    content_sha256 = data.utilities_local_directory.release.content_sha256,
+    file_count     = data.utilities_local_directory.release.file_count,
+    total_size     = data.utilities_local_directory.release.total_size,
+  }
+}
@@ -0,0 +1,13 @@
+resource "utilities_decompress_file" "dump" {
+  source           = "./external/dump.sql.gz"
+  destination_file = "/var/lib/restore/dump.sql"
+  file_mode        = "0600"
+}
+
+resource "utilities_decompress_file" "binary" {
+  url              = "https://example.com/releases/tool-linux-amd64.zst"
+  destination_file = "/usr/local/bin/tool"
+  owner            = "root"
+  group            = "root"
+  file_mode        = "0755"
+}
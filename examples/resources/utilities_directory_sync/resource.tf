@@ -0,0 +1,8 @@
+resource "utilities_directory_sync" "site" {
+  source_dir        = "${path.module}/site"
+  destination_dir   = "/var/www/site"
+  compare           = "checksum"
+  delete_extraneous = true
+  exclude           = [".git", "**/*.tmp"]
+  preserve_mode     = true
+}
@@ -0,0 +1,8 @@
+terraform {
+  required_providers {
+    utilities = {
+      source  = "hashicorp.com/tfstack/utilities"
+      version = "0.1.10"
+    }
+  }
+}
@@ -0,0 +1,13 @@
+resource "utilities_extract_package" "deb" {
+  destination = "/opt/jq"
+  source      = "./external/jq_1.7.1-3_amd64.deb"
+}
+
+resource "utilities_extract_package" "rpm" {
+  destination = "/opt/jq"
+  url         = "https://example.com/packages/jq-1.7.1-8.el10.x86_64.rpm"
+
+  rename = [
+    { pattern = "^usr/" },
+  ]
+}
@@ -3,3 +3,9 @@ resource "utilities_local_directory" "example" {
   path        = "/tmp/test"
   permissions = "0750"
 }
+
+resource "utilities_local_directory" "data" {
+  path              = "/srv/data"
+  backup_on_destroy = true
+  backup_dir        = "/var/backups/terraform"
+}
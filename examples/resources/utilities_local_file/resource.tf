@@ -0,0 +1,7 @@
+resource "utilities_local_file" "example" {
+  path        = "/tmp/test/app.conf"
+  content     = "listen = 8080\n"
+  user        = "root"
+  group       = "root"
+  permissions = "0640"
+}
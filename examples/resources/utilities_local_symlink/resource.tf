@@ -0,0 +1,4 @@
+resource "utilities_local_symlink" "current" {
+  path   = "/opt/app/current"
+  target = "/opt/app/releases/1.2.3"
+}
@@ -0,0 +1,10 @@
+resource "utilities_template_directory" "nginx" {
+  source_dir      = "${path.module}/templates/nginx"
+  destination_dir = "/etc/nginx"
+  helpers         = true
+
+  vars = {
+    server_name = "example.com"
+    upstream    = "127.0.0.1:8080"
+  }
+}
@@ -0,0 +1,133 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package fsmeta reads file ownership and resolves user and group names natively, with
+// syscall.Stat_t and os/user, instead of shelling out to stat, ls or id. Names that cannot be
+// resolved, as in distroless images without /etc/passwd, fall back to their numeric IDs.
+package fsmeta
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+)
+
+// Owner is the owner and group of a file.
+type Owner struct {
+	UID   int
+	GID   int
+	User  string
+	Group string
+}
+
+// OwnerOf returns the owner and group of a file from its FileInfo. ok is false where files have
+// no numeric owner, such as on Windows.
+func OwnerOf(info os.FileInfo) (owner Owner, ok bool) {
+	uid, gid, ok := Ownership(info)
+	if !ok {
+		return Owner{UID: -1, GID: -1}, false
+	}
+
+	return Owner{UID: uid, GID: gid, User: UserName(uid), Group: GroupName(gid)}, true
+}
+
+// Stat returns the owner and group of the file at path, following symbolic links.
+func Stat(path string) (Owner, bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return Owner{UID: -1, GID: -1}, false, err
+	}
+
+	owner, ok := OwnerOf(info)
+	return owner, ok, nil
+}
+
+// UserName resolves a UID to a user name, falling back to the numeric ID.
+func UserName(uid int) string {
+	id := strconv.Itoa(uid)
+	if u, err := user.LookupId(id); err == nil {
+		return u.Username
+	}
+
+	return id
+}
+
+// GroupName resolves a GID to a group name, falling back to the numeric ID.
+func GroupName(gid int) string {
+	id := strconv.Itoa(gid)
+	if g, err := user.LookupGroupId(id); err == nil {
+		return g.Name
+	}
+
+	return id
+}
+
+// LookupUID resolves a user name to its numeric UID. A numeric ID is returned as is, so that
+// users without a name can be referenced.
+func LookupUID(userName string) (int, error) {
+	userInfo, err := user.Lookup(userName)
+	if err != nil {
+		if uid, convErr := strconv.Atoi(userName); convErr == nil && uid >= 0 {
+			return uid, nil
+		}
+		return -1, fmt.Errorf("failed to lookup user '%s': %v", userName, err)
+	}
+
+	uid, err := strconv.Atoi(userInfo.Uid)
+	if err != nil {
+		return -1, fmt.Errorf("failed to convert user ID '%s' to integer: %v", userInfo.Uid, err)
+	}
+
+	return uid, nil
+}
+
+// LookupGID resolves a group name to its numeric GID. A numeric ID is returned as is, so that
+// groups without a name can be referenced.
+func LookupGID(groupName string) (int, error) {
+	groupInfo, err := user.LookupGroup(groupName)
+	if err != nil {
+		if gid, convErr := strconv.Atoi(groupName); convErr == nil && gid >= 0 {
+			return gid, nil
+		}
+		return -1, fmt.Errorf("failed to lookup group '%s': %v", groupName, err)
+	}
+
+	gid, err := strconv.Atoi(groupInfo.Gid)
+	if err != nil {
+		return -1, fmt.Errorf("failed to convert GID '%s' to integer: %v", groupInfo.Gid, err)
+	}
+
+	return gid, nil
+}
+
+// CurrentUserName returns the name of the current user, falling back to the numeric UID when
+// the user has no passwd entry.
+func CurrentUserName() (string, error) {
+	currentUser, err := user.Current()
+	if err == nil && currentUser.Username != "" {
+		return currentUser.Username, nil
+	}
+	if uid := os.Getuid(); uid >= 0 {
+		return strconv.Itoa(uid), nil
+	}
+
+	return "", fmt.Errorf("failed to retrieve the current user: %v", err)
+}
+
+// CurrentGroupName returns the name of the primary group of the current user, falling back to
+// the numeric GID.
+func CurrentGroupName() (string, error) {
+	gid := strconv.Itoa(os.Getgid())
+	if currentUser, err := user.Current(); err == nil {
+		gid = currentUser.Gid
+	} else if os.Getgid() < 0 {
+		return "", fmt.Errorf("failed to retrieve the current user: %v", err)
+	}
+
+	if g, err := user.LookupGroupId(gid); err == nil {
+		return g.Name, nil
+	}
+
+	return gid, nil
+}
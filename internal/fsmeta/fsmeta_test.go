@@ -0,0 +1,74 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package fsmeta
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+)
+
+func TestStat(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("ownership is not supported on Windows")
+	}
+
+	path := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	owner, ok, err := Stat(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !ok {
+		t.Fatal("expected the ownership to be available")
+	}
+	if owner.UID != os.Getuid() || owner.GID != os.Getgid() {
+		t.Errorf("expected %d:%d, got %d:%d", os.Getuid(), os.Getgid(), owner.UID, owner.GID)
+	}
+
+	currentUser, err := CurrentUserName()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if owner.User != currentUser {
+		t.Errorf("expected user '%s', got '%s'", currentUser, owner.User)
+	}
+
+	if _, _, err := Stat(filepath.Join(t.TempDir(), "missing")); !os.IsNotExist(err) {
+		t.Errorf("expected a not exist error, got %v", err)
+	}
+}
+
+func TestNumericFallback(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("numeric IDs are not supported on Windows")
+	}
+
+	// IDs without a passwd or group entry resolve to themselves
+	const unnamed = 2147483000
+	if name := UserName(unnamed); name != strconv.Itoa(unnamed) {
+		t.Errorf("expected user '%d', got '%s'", unnamed, name)
+	}
+	if name := GroupName(unnamed); name != strconv.Itoa(unnamed) {
+		t.Errorf("expected group '%d', got '%s'", unnamed, name)
+	}
+
+	uid, err := LookupUID(strconv.Itoa(unnamed))
+	if err != nil || uid != unnamed {
+		t.Errorf("expected UID %d, got %d (%v)", unnamed, uid, err)
+	}
+	gid, err := LookupGID(strconv.Itoa(unnamed))
+	if err != nil || gid != unnamed {
+		t.Errorf("expected GID %d, got %d (%v)", unnamed, gid, err)
+	}
+
+	if _, err := LookupUID("no-such-user-for-fsmeta"); err == nil {
+		t.Error("expected an error for an unknown user name")
+	}
+}
@@ -0,0 +1,21 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build !windows
+
+package fsmeta
+
+import (
+	"os"
+	"syscall"
+)
+
+// Ownership returns the numeric owner and group of a file.
+func Ownership(info os.FileInfo) (int, int, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return -1, -1, false
+	}
+
+	return int(stat.Uid), int(stat.Gid), true
+}
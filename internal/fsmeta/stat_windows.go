@@ -0,0 +1,15 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build windows
+
+package fsmeta
+
+import (
+	"os"
+)
+
+// Ownership is not supported on Windows, which has no numeric owner and group.
+func Ownership(info os.FileInfo) (int, int, bool) {
+	return -1, -1, false
+}
@@ -0,0 +1,145 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+type dataSourceFilesystemUsage struct{}
+
+type FilesystemUsage struct {
+	Id             types.String   `tfsdk:"id"`
+	Path           types.String   `tfsdk:"path"`
+	FilesystemType types.String   `tfsdk:"filesystem_type"`
+	MountPoint     types.String   `tfsdk:"mount_point"`
+	MountOptions   []types.String `tfsdk:"mount_options"`
+	TotalBytes     types.Int64    `tfsdk:"total_bytes"`
+	FreeBytes      types.Int64    `tfsdk:"free_bytes"`
+	AvailableBytes types.Int64    `tfsdk:"available_bytes"`
+	TotalInodes    types.Int64    `tfsdk:"total_inodes"`
+	FreeInodes     types.Int64    `tfsdk:"free_inodes"`
+}
+
+func NewDataSourceFilesystemUsage() datasource.DataSource {
+	return &dataSourceFilesystemUsage{}
+}
+
+func (d *dataSourceFilesystemUsage) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// No configuration required for this data source
+}
+
+func (d *dataSourceFilesystemUsage) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = "utilities_filesystem_usage"
+}
+
+func (d *dataSourceFilesystemUsage) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data FilesystemUsage
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	fsPath := data.Path.ValueString()
+	usage, err := statFilesystem(fsPath)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error Retrieving Filesystem Usage",
+			fmt.Sprintf("Failed to retrieve the usage of the filesystem holding '%s': %v", fsPath, err),
+		)
+		return
+	}
+
+	tflog.Debug(ctx, "Retrieved filesystem usage", map[string]interface{}{
+		"path":            fsPath,
+		"mount_point":     usage.MountPoint,
+		"available_bytes": usage.AvailableBytes,
+		"free_inodes":     usage.FreeInodes,
+	})
+
+	data.Id = types.StringValue(fsPath)
+	data.FilesystemType = types.StringValue(usage.Type)
+	data.MountPoint = types.StringValue(usage.MountPoint)
+	data.MountOptions = []types.String{}
+	for _, option := range usage.MountOptions {
+		data.MountOptions = append(data.MountOptions, types.StringValue(option))
+	}
+	data.TotalBytes = types.Int64Value(clampInt64(usage.TotalBytes))
+	data.FreeBytes = types.Int64Value(clampInt64(usage.FreeBytes))
+	data.AvailableBytes = types.Int64Value(clampInt64(usage.AvailableBytes))
+	data.TotalInodes = types.Int64Value(clampInt64(usage.TotalInodes))
+	data.FreeInodes = types.Int64Value(clampInt64(usage.FreeInodes))
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (d *dataSourceFilesystemUsage) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: `
+Provides the size, free space and free inodes of the filesystem holding a path, using ` + "`statfs`" + `. Combine it with a ` + "`precondition`" + ` block to assert, during plan, that there is enough room before extracting or copying large artifacts.
+
+**Note**: The mount point and mount options are read from ` + "`/proc/self/mountinfo`" + ` on Linux and from ` + "`statfs`" + ` on macOS. This data source is **not supported** on other systems.
+`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				MarkdownDescription: "The unique identifier of the data source, which is the same as the path.",
+				Computed:            true,
+			},
+			"path": schema.StringAttribute{
+				MarkdownDescription: "A file or directory on the filesystem to inspect. It must exist.",
+				Required:            true,
+			},
+			"filesystem_type": schema.StringAttribute{
+				MarkdownDescription: "The type of the filesystem (e.g., `ext4`, `xfs`, `tmpfs` or `apfs`).",
+				Computed:            true,
+			},
+			"mount_point": schema.StringAttribute{
+				MarkdownDescription: "The directory the filesystem is mounted on.",
+				Computed:            true,
+			},
+			"mount_options": schema.ListAttribute{
+				ElementType:         types.StringType,
+				MarkdownDescription: "The options the filesystem is mounted with (e.g., `rw`, `nosuid`, `noexec`).",
+				Computed:            true,
+			},
+			"total_bytes": schema.Int64Attribute{
+				MarkdownDescription: "The size of the filesystem in bytes.",
+				Computed:            true,
+			},
+			"free_bytes": schema.Int64Attribute{
+				MarkdownDescription: "The free space in bytes, including the space reserved for the root user.",
+				Computed:            true,
+			},
+			"available_bytes": schema.Int64Attribute{
+				MarkdownDescription: "The free space in bytes available to unprivileged users. Use this to check whether an artifact fits.",
+				Computed:            true,
+			},
+			"total_inodes": schema.Int64Attribute{
+				MarkdownDescription: "The number of inodes of the filesystem. Some filesystems, such as btrfs, report 0.",
+				Computed:            true,
+			},
+			"free_inodes": schema.Int64Attribute{
+				MarkdownDescription: "The number of free inodes, which limits the number of files that can still be created.",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+// clampInt64 converts an unsigned count to int64, saturating values Terraform numbers in the
+// schema cannot hold.
+func clampInt64(value uint64) int64 {
+	if value > math.MaxInt64 {
+		return math.MaxInt64
+	}
+
+	return int64(value)
+}
@@ -0,0 +1,45 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestDataSourceFilesystemUsage(t *testing.T) {
+	tempDir := t.TempDir()
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: TestAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+data "utilities_filesystem_usage" "test" {
+  path = "%s"
+}`, tempDir),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.utilities_filesystem_usage.test", "id", tempDir),
+					resource.TestCheckResourceAttrSet("data.utilities_filesystem_usage.test", "filesystem_type"),
+					resource.TestCheckResourceAttrSet("data.utilities_filesystem_usage.test", "mount_point"),
+					resource.TestCheckResourceAttrSet("data.utilities_filesystem_usage.test", "mount_options.0"),
+					resource.TestMatchResourceAttr("data.utilities_filesystem_usage.test", "total_bytes", regexp.MustCompile(`^[1-9][0-9]*$`)),
+					resource.TestCheckResourceAttrSet("data.utilities_filesystem_usage.test", "available_bytes"),
+					resource.TestCheckResourceAttrSet("data.utilities_filesystem_usage.test", "free_inodes"),
+				),
+			},
+			{
+				Config: fmt.Sprintf(`
+data "utilities_filesystem_usage" "test" {
+  path = "%s"
+}`, filepath.Join(tempDir, "missing")),
+				ExpectError: regexp.MustCompile(`Error Retrieving Filesystem Usage`),
+			},
+		},
+	})
+}
@@ -5,20 +5,54 @@ package provider
 
 import (
 	"context"
+	"crypto/sha256"
 	"fmt"
+	"io/fs"
 	"os"
-	"os/exec"
-	"runtime"
-	"strings"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"terraform-provider-utilities/internal/fsmeta"
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
-	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
+const (
+	directoryEntryFile      = "file"
+	directoryEntryDirectory = "directory"
+	directoryEntrySymlink   = "symlink"
+	directoryEntryOther     = "other"
+)
+
 type dataSourceLocalDirectory struct{}
 
+type LocalDirectoryDataSource struct {
+	Id            types.String          `tfsdk:"id"`
+	Exists        types.Bool            `tfsdk:"exists"`
+	Path          types.String          `tfsdk:"path"`
+	Permissions   types.String          `tfsdk:"permissions"`
+	User          types.String          `tfsdk:"user"`
+	Group         types.String          `tfsdk:"group"`
+	Recursive     types.Bool            `tfsdk:"recursive"`
+	Include       []types.String        `tfsdk:"include"`
+	Exclude       []types.String        `tfsdk:"exclude"`
+	Entries       []LocalDirectoryEntry `tfsdk:"entries"`
+	TotalSize     types.Int64           `tfsdk:"total_size"`
+	FileCount     types.Int64           `tfsdk:"file_count"`
+	ContentSHA256 types.String          `tfsdk:"content_sha256"`
+}
+
+type LocalDirectoryEntry struct {
+	Path    types.String `tfsdk:"path"`
+	Type    types.String `tfsdk:"type"`
+	Size    types.Int64  `tfsdk:"size"`
+	Mode    types.String `tfsdk:"mode"`
+	ModTime types.String `tfsdk:"mtime"`
+}
+
 func NewDataSourceLocalDirectory() datasource.DataSource {
 	return &dataSourceLocalDirectory{}
 }
@@ -32,82 +66,59 @@ func (d *dataSourceLocalDirectory) Metadata(_ context.Context, req datasource.Me
 }
 
 func (d *dataSourceLocalDirectory) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
-	var pathAttribute types.String
-	diags := req.Config.GetAttribute(ctx, path.Root("path"), &pathAttribute)
-	resp.Diagnostics.Append(diags...)
+	var data LocalDirectoryDataSource
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	directoryPath := pathAttribute.ValueString()
+	directoryPath := data.Path.ValueString()
 
-	exists := false
-	permissions := ""
-	userName := ""
-	groupName := ""
-	id := directoryPath
+	data.Id = types.StringValue(directoryPath)
+	data.Exists = types.BoolValue(false)
+	data.Permissions = types.StringValue("")
+	data.User = types.StringValue("")
+	data.Group = types.StringValue("")
+	data.Entries = []LocalDirectoryEntry{}
+	data.TotalSize = types.Int64Value(0)
+	data.FileCount = types.Int64Value(0)
+	data.ContentSHA256 = types.StringValue("")
 
 	info, err := os.Stat(directoryPath)
 	if err == nil && info.IsDir() {
-		exists = true
-
-		permissions = fmt.Sprintf("%04o", info.Mode().Perm())
-
-		if runtime.GOOS != "windows" {
-			cmd := exec.Command("ls", "-ld", directoryPath)
-			output, err := cmd.Output()
-			if err != nil {
-				resp.Diagnostics.AddError(
-					"Error Fetching Directory Metadata",
-					fmt.Sprintf("Failed to retrieve metadata for '%s': %v", directoryPath, err),
-				)
-				return
-			}
+		data.Exists = types.BoolValue(true)
+		data.Permissions = types.StringValue(fmt.Sprintf("%04o", info.Mode().Perm()))
 
-			fields := strings.Fields(string(output))
-			if len(fields) >= 3 {
-				userName = fields[2]
-				groupName = fields[3]
-			} else {
-				resp.Diagnostics.AddError(
-					"Error Parsing Metadata",
-					"Unexpected output format from `ls -ld` command.",
-				)
-				return
-			}
+		if owner, ok := fsmeta.OwnerOf(info); ok {
+			data.User = types.StringValue(owner.User)
+			data.Group = types.StringValue(owner.Group)
 		} else {
-			// For Windows, set placeholder values
-			userName = "N/A"
-			groupName = "N/A"
+			// Ownership is not available on Windows
+			data.User = types.StringValue("N/A")
+			data.Group = types.StringValue("N/A")
 		}
-	}
 
-	state := struct {
-		Id          types.String `tfsdk:"id"`
-		Exists      types.Bool   `tfsdk:"exists"`
-		Path        types.String `tfsdk:"path"`
-		Permissions types.String `tfsdk:"permissions"`
-		User        types.String `tfsdk:"user"`
-		Group       types.String `tfsdk:"group"`
-	}{
-		Id:          types.StringValue(id),
-		Exists:      types.BoolValue(exists),
-		Path:        types.StringValue(directoryPath),
-		Permissions: types.StringValue(permissions),
-		User:        types.StringValue(userName),
-		Group:       types.StringValue(groupName),
-	}
+		listing, err := listDirectory(directoryPath, data.Recursive.ValueBool(), stringValues(data.Include), stringValues(data.Exclude))
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Error Listing Directory",
+				fmt.Sprintf("Failed to list the directory '%s': %v", directoryPath, err),
+			)
+			return
+		}
 
-	diags = resp.State.Set(ctx, &state)
-	resp.Diagnostics.Append(diags...)
-	if resp.Diagnostics.HasError() {
-		return
+		data.Entries = listing.Entries
+		data.TotalSize = types.Int64Value(listing.TotalSize)
+		data.FileCount = types.Int64Value(listing.FileCount)
+		data.ContentSHA256 = types.StringValue(listing.ContentSHA256)
 	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
 func (d *dataSourceLocalDirectory) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		MarkdownDescription: "Provides information about a local directory, including its metadata and permissions.",
+		MarkdownDescription: "Provides information about a local directory, including its metadata, permissions and contents.",
 		Attributes: map[string]schema.Attribute{
 			"id": schema.StringAttribute{
 				MarkdownDescription: "The unique identifier for the local directory, which is the same as the path.",
@@ -126,13 +137,192 @@ func (d *dataSourceLocalDirectory) Schema(ctx context.Context, req datasource.Sc
 				Computed:            true,
 			},
 			"user": schema.StringAttribute{
-				MarkdownDescription: "The name of the user owning the directory.",
+				MarkdownDescription: "The name of the user owning the directory, or its numeric ID if it has no name.",
 				Computed:            true,
 			},
 			"group": schema.StringAttribute{
-				MarkdownDescription: "The name of the group owning the directory.",
+				MarkdownDescription: "The name of the group owning the directory, or its numeric ID if it has no name.",
+				Computed:            true,
+			},
+			"recursive": schema.BoolAttribute{
+				MarkdownDescription: "Whether to list the whole tree below the directory. Otherwise only its direct entries are listed. Defaults to false.",
+				Optional:            true,
+			},
+			"include": schema.ListAttribute{
+				ElementType:         types.StringType,
+				MarkdownDescription: "Glob patterns of the files to list, relative to the directory (e.g., `**/*.conf`). When set, other files are skipped. Directories are always traversed.",
+				Optional:            true,
+			},
+			"exclude": schema.ListAttribute{
+				ElementType:         types.StringType,
+				MarkdownDescription: "Glob patterns of the entries to skip, relative to the directory (e.g., `.git` or `**/*.tmp`). Excluded directories are not traversed.",
+				Optional:            true,
+			},
+			"entries": schema.ListNestedAttribute{
+				MarkdownDescription: "The listed entries, sorted by path. Symbolic links are listed but not followed.",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"path": schema.StringAttribute{
+							MarkdownDescription: "The path of the entry relative to the directory, with `/` separators.",
+							Computed:            true,
+						},
+						"type": schema.StringAttribute{
+							MarkdownDescription: "The type of the entry: `file`, `directory`, `symlink` or `other`.",
+							Computed:            true,
+						},
+						"size": schema.Int64Attribute{
+							MarkdownDescription: "The size of the entry in bytes.",
+							Computed:            true,
+						},
+						"mode": schema.StringAttribute{
+							MarkdownDescription: "Permissions of the entry in octal format (e.g., 0644).",
+							Computed:            true,
+						},
+						"mtime": schema.StringAttribute{
+							MarkdownDescription: "The modification time of the entry in RFC 3339 format.",
+							Computed:            true,
+						},
+					},
+				},
+			},
+			"total_size": schema.Int64Attribute{
+				MarkdownDescription: "The total size in bytes of the listed files.",
+				Computed:            true,
+			},
+			"file_count": schema.Int64Attribute{
+				MarkdownDescription: "The number of listed files.",
+				Computed:            true,
+			},
+			"content_sha256": schema.StringAttribute{
+				MarkdownDescription: "A SHA-256 hash over the paths and types of the listed entries, the contents of the files and the targets of the symbolic links. It only changes when the listed contents change, not when permissions or modification times do.",
 				Computed:            true,
 			},
 		},
 	}
 }
+
+// directoryListing is the result of listDirectory.
+type directoryListing struct {
+	Entries       []LocalDirectoryEntry
+	TotalSize     int64
+	FileCount     int64
+	ContentSHA256 string
+}
+
+// listDirectory lists the entries below root, optionally recursively, and hashes the listed
+// tree. Entries are read with Lstat, so symbolic links below root are reported but never
+// followed. A root that is itself a link is resolved first.
+func listDirectory(root string, recursive bool, include, exclude []string) (directoryListing, error) {
+	listing := directoryListing{Entries: []LocalDirectoryEntry{}}
+	var relPaths []string
+	infos := map[string]os.FileInfo{}
+
+	// WalkDir does not descend into a root that is a symbolic link, such as a "current" release link
+	root, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return listing, err
+	}
+
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == root {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if matchAnyGlob(exclude, rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || len(include) == 0 || matchAnyGlob(include, rel) {
+			relPaths = append(relPaths, rel)
+			infos[rel] = info
+		}
+
+		if d.IsDir() && !recursive {
+			return filepath.SkipDir
+		}
+
+		return nil
+	})
+	if err != nil {
+		return listing, err
+	}
+
+	// WalkDir visits entries in lexical order, sort again to order by the slash-separated path
+	sort.Strings(relPaths)
+
+	hash := sha256.New()
+	for _, rel := range relPaths {
+		info := infos[rel]
+		entryType := directoryEntryType(info)
+
+		listing.Entries = append(listing.Entries, LocalDirectoryEntry{
+			Path:    types.StringValue(rel),
+			Type:    types.StringValue(entryType),
+			Size:    types.Int64Value(info.Size()),
+			Mode:    types.StringValue(fmt.Sprintf("%04o", info.Mode().Perm())),
+			ModTime: types.StringValue(info.ModTime().UTC().Format(time.RFC3339)),
+		})
+
+		// Each record is the type, the path and the content, separated by NUL bytes
+		var content string
+		switch entryType {
+		case directoryEntryFile:
+			listing.TotalSize += info.Size()
+			listing.FileCount++
+			if content, err = calculateFileHash(filepath.Join(root, filepath.FromSlash(rel))); err != nil {
+				return listing, err
+			}
+		case directoryEntrySymlink:
+			if content, err = os.Readlink(filepath.Join(root, filepath.FromSlash(rel))); err != nil {
+				return listing, err
+			}
+		}
+		fmt.Fprintf(hash, "%s\x00%s\x00%s\n", entryType, rel, content)
+	}
+	listing.ContentSHA256 = fmt.Sprintf("%x", hash.Sum(nil))
+
+	return listing, nil
+}
+
+// directoryEntryType returns the listed type of an entry.
+func directoryEntryType(info os.FileInfo) string {
+	switch {
+	case info.Mode().IsRegular():
+		return directoryEntryFile
+	case info.IsDir():
+		return directoryEntryDirectory
+	case info.Mode()&os.ModeSymlink != 0:
+		return directoryEntrySymlink
+	default:
+		return directoryEntryOther
+	}
+}
+
+// stringValues returns the values of a list of strings, skipping null elements.
+func stringValues(values []types.String) []string {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if !value.IsNull() && !value.IsUnknown() {
+			result = append(result, value.ValueString())
+		}
+	}
+
+	return result
+}
@@ -4,6 +4,7 @@
 package provider
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -32,6 +33,75 @@ func TestDataSourceLocalDirectory(t *testing.T) {
 	})
 }
 
+func TestDataSourceLocalDirectoryListing(t *testing.T) {
+	tempDir := t.TempDir()
+
+	requireNoError(t, os.MkdirAll(filepath.Join(tempDir, "conf.d"), 0755))
+	requireNoError(t, os.WriteFile(filepath.Join(tempDir, "app.conf"), []byte("name = web\n"), 0644))
+	requireNoError(t, os.WriteFile(filepath.Join(tempDir, "conf.d", "site.conf"), []byte("listen = 80\n"), 0600))
+	requireNoError(t, os.WriteFile(filepath.Join(tempDir, "conf.d", "site.conf.tmp"), []byte("scratch\n"), 0644))
+
+	config := fmt.Sprintf(`
+data "utilities_local_directory" "test" {
+  path      = "%s"
+  recursive = true
+  exclude   = ["**/*.tmp"]
+}`, tempDir)
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: TestAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.utilities_local_directory.test", "entries.#", "3"),
+					resource.TestCheckResourceAttr("data.utilities_local_directory.test", "entries.0.path", "app.conf"),
+					resource.TestCheckResourceAttr("data.utilities_local_directory.test", "entries.0.type", "file"),
+					resource.TestCheckResourceAttr("data.utilities_local_directory.test", "entries.0.size", "11"),
+					resource.TestCheckResourceAttr("data.utilities_local_directory.test", "entries.1.path", "conf.d"),
+					resource.TestCheckResourceAttr("data.utilities_local_directory.test", "entries.1.type", "directory"),
+					resource.TestCheckResourceAttr("data.utilities_local_directory.test", "entries.2.path", "conf.d/site.conf"),
+					resource.TestCheckResourceAttr("data.utilities_local_directory.test", "entries.2.mode", "0600"),
+					resource.TestCheckResourceAttr("data.utilities_local_directory.test", "file_count", "2"),
+					resource.TestCheckResourceAttr("data.utilities_local_directory.test", "total_size", "23"),
+					resource.TestCheckResourceAttrSet("data.utilities_local_directory.test", "content_sha256"),
+				),
+			},
+		},
+	})
+}
+
+func TestDataSourceLocalDirectorySymlink(t *testing.T) {
+	tempDir := t.TempDir()
+	releaseDir := filepath.Join(tempDir, "releases", "v1")
+	currentLink := filepath.Join(tempDir, "current")
+
+	requireNoError(t, os.MkdirAll(releaseDir, 0755))
+	requireNoError(t, os.WriteFile(filepath.Join(releaseDir, "app.conf"), []byte("name = web\n"), 0644))
+	requireNoError(t, os.Symlink(releaseDir, currentLink))
+
+	// A path that is a link to a directory lists the directory it points to
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV6ProviderFactories: TestAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+data "utilities_local_directory" "test" {
+  path      = "%s"
+  recursive = true
+}`, currentLink),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.utilities_local_directory.test", "exists", "true"),
+					resource.TestCheckResourceAttr("data.utilities_local_directory.test", "entries.#", "1"),
+					resource.TestCheckResourceAttr("data.utilities_local_directory.test", "entries.0.path", "app.conf"),
+					resource.TestCheckResourceAttr("data.utilities_local_directory.test", "file_count", "1"),
+					resource.TestCheckResourceAttr("data.utilities_local_directory.test", "total_size", "11"),
+				),
+			},
+		},
+	})
+}
+
 func testConfigLocalDirectory(dir string) string {
 	return `
 data "utilities_local_directory" "test" {
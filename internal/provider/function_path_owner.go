@@ -5,10 +5,8 @@ package provider
 
 import (
 	"context"
-	"os"
-	"os/exec"
-	"runtime"
-	"strings"
+
+	"terraform-provider-utilities/internal/fsmeta"
 
 	"github.com/hashicorp/terraform-plugin-framework/function"
 	"github.com/hashicorp/terraform-plugin-framework/types"
@@ -37,7 +35,7 @@ Returns the owner (username) of the specified file or directory path.
 - **path**: The file or directory path to check.
 
 ### Returns
-- **string**: The username of the file or directory owner.
+- **string**: The username of the file or directory owner, or its numeric ID if the user has no name.
 `,
 		Parameters: []function.Parameter{
 			function.StringParameter{
@@ -71,41 +69,18 @@ func (f *PathOwnerFunction) Run(ctx context.Context, req function.RunRequest, re
 		return
 	}
 
-	// Get file information
-	_, err := os.Stat(inputs.Path)
+	// Get the owner from the file information, without shelling out to `stat`
+	fileOwner, ok, err := fsmeta.Stat(inputs.Path)
 	if err != nil {
 		resp.Error = function.NewArgumentFuncError(1, "Error retrieving path information")
 		tflog.Error(ctx, "Error retrieving path information", map[string]interface{}{"path": inputs.Path, "error": err.Error()})
 		return
 	}
 
-	var owner string
-
-	// On Unix-based systems, use system calls to get UID and GID without Sys()
-	if runtime.GOOS != "windows" {
-		// Retrieve the user and group via command-line tools (like `stat` or `ls -l`)
-		// Using "stat" command (POSIX) to get user and group information
-		cmd := exec.Command("stat", "-c", "%U:%G", inputs.Path)
-		output, err := cmd.CombinedOutput()
-		if err != nil {
-			resp.Error = function.NewArgumentFuncError(1, "Error retrieving file owner information")
-			tflog.Error(ctx, "Error retrieving file owner information", map[string]interface{}{"path": inputs.Path, "error": err.Error()})
-			return
-		}
-
-		// Parse the output of the stat command
-		parts := strings.Split(string(output), ":")
-		if len(parts) != 2 {
-			resp.Error = function.NewArgumentFuncError(1, "Invalid stat output")
-			tflog.Error(ctx, "Invalid stat output", map[string]interface{}{"path": inputs.Path, "output": string(output)})
-			return
-		}
-
-		// Assign the parsed user and group
-		owner = parts[0]
-	} else {
-		// For Windows systems, set the owner to "unknown"
-		owner = "unknown"
+	// Windows has no numeric owner, set the owner to "unknown"
+	owner := "unknown"
+	if ok {
+		owner = fileOwner.User
 	}
 
 	tflog.Debug(ctx, "File owner retrieved", map[string]interface{}{
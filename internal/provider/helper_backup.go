@@ -0,0 +1,134 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// backupTimeFormat is the timestamp in backup file names, which sorts chronologically.
+const backupTimeFormat = "20060102T150405Z"
+
+// backupDirectory writes a gzip-compressed tar archive of source to backupDir, named after the
+// directory and the current time, and returns its path. Entries are stored below the base name
+// of source, with their permissions, ownership and modification times. Symbolic links are stored
+// as links, including source itself, which is what deleting it removes. The archive is written under a temporary name and renamed once complete, so that an
+// interrupted backup never looks like a finished one.
+func backupDirectory(source, backupDir string) (string, error) {
+	if err := os.MkdirAll(backupDir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create backup directory '%s': %w", backupDir, err)
+	}
+
+	base := filepath.Base(filepath.Clean(source))
+	name := fmt.Sprintf("%s-%s", base, time.Now().UTC().Format(backupTimeFormat))
+	backupPath := filepath.Join(backupDir, name+".tar.gz")
+	for i := 1; ; i++ {
+		if _, err := os.Lstat(backupPath); os.IsNotExist(err) {
+			break
+		}
+		backupPath = filepath.Join(backupDir, fmt.Sprintf("%s-%d.tar.gz", name, i))
+	}
+
+	tmp, err := os.CreateTemp(backupDir, "."+name+".*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("failed to create backup file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer func() { _ = os.Remove(tmpPath) }()
+
+	if err := writeTarGz(tmp, source, base); err != nil {
+		_ = tmp.Close()
+		return "", err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return "", fmt.Errorf("failed to sync backup file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to close backup file: %w", err)
+	}
+	if err := os.Rename(tmpPath, backupPath); err != nil {
+		return "", fmt.Errorf("failed to rename backup file to '%s': %w", backupPath, err)
+	}
+
+	return backupPath, nil
+}
+
+// writeTarGz archives the tree below source to w, storing its entries below prefix.
+func writeTarGz(w io.Writer, source, prefix string) error {
+	gzipWriter := gzip.NewWriter(w)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	err := filepath.WalkDir(source, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(source, p)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		// Sockets cannot be archived and are meaningless once their server is gone
+		if info.Mode()&os.ModeSocket != 0 {
+			return nil
+		}
+
+		var link string
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(p); err != nil {
+				return err
+			}
+		}
+
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return fmt.Errorf("failed to archive '%s': %w", p, err)
+		}
+		header.Name = filepath.ToSlash(filepath.Join(prefix, rel))
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return fmt.Errorf("failed to archive '%s': %w", p, err)
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		file, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = file.Close() }()
+		if _, err := io.Copy(tarWriter, file); err != nil {
+			return fmt.Errorf("failed to archive '%s': %w", p, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return fmt.Errorf("failed to finish archive: %w", err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return fmt.Errorf("failed to finish archive: %w", err)
+	}
+
+	return nil
+}
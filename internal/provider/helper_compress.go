@@ -0,0 +1,125 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+	"github.com/ulikunitz/xz/lzma"
+)
+
+const (
+	compressionNone  = "none"
+	compressionGzip  = "gzip"
+	compressionXz    = "xz"
+	compressionLzma  = "lzma"
+	compressionBzip2 = "bzip2"
+	compressionZstd  = "zstd"
+)
+
+// decompressReadCloser closes the decompressor but not the underlying reader.
+type decompressReadCloser struct {
+	io.Reader
+	close func() error
+}
+
+func (d decompressReadCloser) Close() error {
+	return d.close()
+}
+
+// compressionFromExtension returns the compression of a file based on its name.
+func compressionFromExtension(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".gz"), strings.HasSuffix(lower, ".tgz"):
+		return compressionGzip
+	case strings.HasSuffix(lower, ".xz"), strings.HasSuffix(lower, ".txz"):
+		return compressionXz
+	case strings.HasSuffix(lower, ".lzma"):
+		return compressionLzma
+	case strings.HasSuffix(lower, ".bz2"), strings.HasSuffix(lower, ".tbz2"):
+		return compressionBzip2
+	case strings.HasSuffix(lower, ".zst"), strings.HasSuffix(lower, ".zstd"):
+		return compressionZstd
+	default:
+		return compressionNone
+	}
+}
+
+// detectCompression determines the compression of a file from its leading bytes, falling back
+// to its extension.
+func detectCompression(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = file.Close() }()
+
+	magic := make([]byte, 6)
+	n, _ := io.ReadFull(file, magic)
+	magic = magic[:n]
+
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		return compressionGzip, nil
+	case bytes.HasPrefix(magic, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}):
+		return compressionXz, nil
+	case bytes.HasPrefix(magic, []byte("BZh")):
+		return compressionBzip2, nil
+	case bytes.HasPrefix(magic, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		return compressionZstd, nil
+	}
+
+	if compression := compressionFromExtension(path); compression != compressionNone {
+		return compression, nil
+	}
+
+	return "", fmt.Errorf("unable to detect the compression of '%s'", path)
+}
+
+// newDecompressReader wraps r with a decompressor for the given compression.
+func newDecompressReader(r io.Reader, compression string) (io.ReadCloser, error) {
+	switch compression {
+	case compressionNone, "":
+		return io.NopCloser(r), nil
+	case compressionGzip:
+		return gzip.NewReader(r)
+	case compressionXz:
+		xzReader, err := xz.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(xzReader), nil
+	case compressionLzma:
+		lzmaReader, err := lzma.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(lzmaReader), nil
+	case compressionBzip2:
+		return io.NopCloser(bzip2.NewReader(r)), nil
+	case compressionZstd:
+		decoder, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return decompressReadCloser{
+			Reader: decoder,
+			close: func() error {
+				decoder.Close()
+				return nil
+			},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported compression '%s'", compression)
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	cpioMagicNewc = "070701"
+	cpioMagicCRC  = "070702"
+	cpioMagicOdc  = "070707"
+
+	cpioTrailer = "TRAILER!!!"
+
+	// cpioMaxNameSize bounds the entry name read from an untrusted archive.
+	cpioMaxNameSize = 64 * 1024
+)
+
+// cpioReader reads the entries of a cpio archive in the "newc", "crc" or "odc" format. Entries
+// are returned as tar headers so that they can be extracted like tar entries.
+type cpioReader struct {
+	r         io.Reader
+	remaining int64
+	padding   int64
+
+	// links maps the device and inode of hard linked files to the name of their first entry
+	links map[string]string
+}
+
+func newCpioReader(r io.Reader) *cpioReader {
+	return &cpioReader{r: r, links: map[string]string{}}
+}
+
+// Next advances to the next entry and returns io.EOF after the trailer.
+func (c *cpioReader) Next() (*tar.Header, error) {
+	// Skip the unread contents of the previous entry
+	if c.remaining+c.padding > 0 {
+		if _, err := io.CopyN(io.Discard, c.r, c.remaining+c.padding); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		c.remaining, c.padding = 0, 0
+	}
+
+	magic := make([]byte, 6)
+	if _, err := io.ReadFull(c.r, magic); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	var mode, uid, gid, mtime, nameSize, fileSize, ino, nlink int64
+	var dev string
+	var err error
+	aligned := false
+
+	switch string(magic) {
+	case cpioMagicNewc, cpioMagicCRC:
+		fields := make([]byte, 104)
+		if _, err := io.ReadFull(c.r, fields); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		hex := func(i int) int64 {
+			if err != nil {
+				return 0
+			}
+			var value uint64
+			value, err = strconv.ParseUint(string(fields[i*8:i*8+8]), 16, 32)
+			return int64(value)
+		}
+		mode, uid, gid, mtime, fileSize, nameSize = hex(1), hex(2), hex(3), hex(5), hex(6), hex(11)
+		ino, nlink, dev = hex(0), hex(4), fmt.Sprintf("%d:%d", hex(7), hex(8))
+		aligned = true
+	case cpioMagicOdc:
+		fields := make([]byte, 70)
+		if _, err := io.ReadFull(c.r, fields); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		octal := func(start, end int) int64 {
+			if err != nil {
+				return 0
+			}
+			var value uint64
+			value, err = strconv.ParseUint(string(fields[start:end]), 8, 64)
+			return int64(value)
+		}
+		mode, uid, gid, mtime, nameSize, fileSize = octal(12, 18), octal(18, 24), octal(24, 30), octal(42, 53), octal(53, 59), octal(59, 70)
+		ino, nlink, dev = octal(6, 12), octal(30, 36), fmt.Sprintf("%d", octal(0, 6))
+	default:
+		return nil, fmt.Errorf("invalid cpio header magic '%x'", magic)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid cpio header: %w", err)
+	}
+	if nameSize <= 0 || nameSize > cpioMaxNameSize {
+		return nil, fmt.Errorf("invalid cpio entry name size %d", nameSize)
+	}
+
+	nameBytes := make([]byte, nameSize)
+	if _, err := io.ReadFull(c.r, nameBytes); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if aligned {
+		// The header and name are padded to a multiple of four bytes
+		if pad := (4 - (110+nameSize)%4) % 4; pad > 0 {
+			if _, err := io.CopyN(io.Discard, c.r, pad); err != nil {
+				return nil, unexpectedEOF(err)
+			}
+		}
+		c.padding = (4 - fileSize%4) % 4
+	}
+	c.remaining = fileSize
+
+	name := strings.TrimRight(string(nameBytes), "\x00")
+	if name == cpioTrailer {
+		return nil, io.EOF
+	}
+
+	header := &tar.Header{
+		Name:    name,
+		Mode:    mode & 07777,
+		Uid:     int(uid),
+		Gid:     int(gid),
+		Size:    fileSize,
+		ModTime: time.Unix(mtime, 0),
+	}
+
+	switch mode & 0170000 {
+	case 0040000:
+		header.Typeflag = tar.TypeDir
+		header.Size = 0
+	case 0100000:
+		header.Typeflag = tar.TypeReg
+		// Later entries of a hard linked file link to the first one. The contents usually come
+		// with the last entry and are written through the link.
+		if nlink > 1 {
+			key := fmt.Sprintf("%s:%d", dev, ino)
+			if first, ok := c.links[key]; ok {
+				header.Typeflag = tar.TypeLink
+				header.Linkname = first
+			} else {
+				c.links[key] = name
+			}
+		}
+	case 0120000:
+		// The contents of a symbolic link entry are its target
+		if fileSize > cpioMaxNameSize {
+			return nil, fmt.Errorf("invalid cpio symbolic link target size %d", fileSize)
+		}
+		target := make([]byte, fileSize)
+		if _, err := io.ReadFull(c.r, target); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		c.remaining = 0
+		header.Typeflag = tar.TypeSymlink
+		header.Linkname = string(target)
+		header.Size = 0
+	case 0020000:
+		header.Typeflag = tar.TypeChar
+	case 0060000:
+		header.Typeflag = tar.TypeBlock
+	case 0010000:
+		header.Typeflag = tar.TypeFifo
+	default:
+		header.Typeflag = tar.TypeReg
+	}
+
+	return header, nil
+}
+
+// Read reads the contents of the current entry.
+func (c *cpioReader) Read(p []byte) (int, error) {
+	if c.remaining <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > c.remaining {
+		p = p[:c.remaining]
+	}
+
+	n, err := c.r.Read(p)
+	c.remaining -= int64(n)
+	if err == io.EOF && c.remaining > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+
+	return n, err
+}
+
+// unexpectedEOF reports a truncated archive instead of a clean end of archive.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
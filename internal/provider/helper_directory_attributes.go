@@ -0,0 +1,468 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"terraform-provider-utilities/internal/fsmeta"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// ACL entry tags as written in the configuration. User and group entries with a qualifier are
+// named entries; without one they apply to the owner and owning group.
+const (
+	aclTagUser  = "user"
+	aclTagGroup = "group"
+	aclTagMask  = "mask"
+	aclTagOther = "other"
+)
+
+var errPosixACLUnsupported = errors.New("POSIX ACLs are not supported on this platform")
+
+// LocalDirectoryACLEntry is a single entry of the acl and default_acl attributes.
+type LocalDirectoryACLEntry struct {
+	Tag         types.String `tfsdk:"tag"`
+	Qualifier   types.String `tfsdk:"qualifier"`
+	Permissions types.String `tfsdk:"permissions"`
+}
+
+// aclSchema returns the schema of the acl and default_acl attributes.
+func aclSchema(description string) schema.SetNestedAttribute {
+	return schema.SetNestedAttribute{
+		Optional:            true,
+		MarkdownDescription: description,
+		NestedObject: schema.NestedAttributeObject{
+			Attributes: map[string]schema.Attribute{
+				"tag": schema.StringAttribute{
+					Required:            true,
+					MarkdownDescription: "Kind of entry: `user`, `group`, `mask` or `other`.",
+					Validators: []validator.String{
+						stringvalidator.OneOf(aclTagUser, aclTagGroup, aclTagMask, aclTagOther),
+					},
+				},
+				"qualifier": schema.StringAttribute{
+					Optional:            true,
+					MarkdownDescription: "Name or numeric ID of the user or group of a named entry, e.g. `ci`. Without a qualifier, `user` and `group` entries apply to the owner and the owning group.",
+					Validators: []validator.String{
+						stringvalidator.LengthAtLeast(1),
+					},
+				},
+				"permissions": schema.StringAttribute{
+					Required:            true,
+					MarkdownDescription: "Permissions granted by the entry, e.g. `rwx` or `r-x`.",
+					Validators: []validator.String{
+						stringvalidator.RegexMatches(regexp.MustCompile(`^[r-][w-][x-]$`), "must be permissions such as rwx or r-x"),
+					},
+				},
+			},
+		},
+	}
+}
+
+// posixACLEntry converts the entry to its kernel representation.
+func (e LocalDirectoryACLEntry) posixACLEntry() (posixACLEntry, error) {
+	entry := posixACLEntry{id: aclUndefinedID}
+	qualifier := e.Qualifier.ValueString()
+
+	switch e.Tag.ValueString() {
+	case aclTagUser:
+		entry.tag = aclUserObj
+		if qualifier != "" {
+			entry.tag = aclUser
+		}
+	case aclTagGroup:
+		entry.tag = aclGroupObj
+		if qualifier != "" {
+			entry.tag = aclGroup
+		}
+	case aclTagMask:
+		entry.tag = aclMask
+	case aclTagOther:
+		entry.tag = aclOther
+	default:
+		return entry, fmt.Errorf("unknown tag '%s'", e.Tag.ValueString())
+	}
+
+	perm, err := parseACLPermissions(e.Permissions.ValueString())
+	if err != nil {
+		return entry, err
+	}
+	entry.perm = perm
+
+	if entry.tag == aclUser || entry.tag == aclGroup {
+		id, err := aclQualifierID(qualifier, entry.tag == aclUser)
+		if err != nil {
+			return entry, err
+		}
+		entry.id = id
+	}
+
+	return entry, nil
+}
+
+// completePosixACL adds the entries the kernel requires but the configuration may leave out. The
+// owner, owning group and other entries are taken from mode. When named entries are present the
+// mask is the union of the group class permissions, as setfacl computes it, unless keepMode is set:
+// the mask then is the group bits of mode, which it replaces, so that the permissions are kept.
+func completePosixACL(entries []posixACLEntry, mode os.FileMode, keepMode bool) []posixACLEntry {
+	present := map[uint16]bool{}
+	named := false
+	var groupClass uint16
+	for _, entry := range entries {
+		present[entry.tag] = true
+		switch entry.tag {
+		case aclUser, aclGroup:
+			named = true
+			groupClass |= entry.perm
+		case aclGroupObj:
+			groupClass |= entry.perm
+		}
+	}
+
+	completed := append([]posixACLEntry{}, entries...)
+	base := []struct {
+		tag  uint16
+		perm uint16
+	}{
+		{aclUserObj, uint16(mode.Perm()>>6) & 7},
+		{aclGroupObj, uint16(mode.Perm()>>3) & 7},
+		{aclOther, uint16(mode.Perm()) & 7},
+	}
+	for _, b := range base {
+		if !present[b.tag] {
+			completed = append(completed, posixACLEntry{tag: b.tag, perm: b.perm, id: aclUndefinedID})
+			if b.tag == aclGroupObj {
+				groupClass |= b.perm
+			}
+		}
+	}
+	if named && !present[aclMask] {
+		if keepMode {
+			groupClass = uint16(mode.Perm()>>3) & 7
+		}
+		completed = append(completed, posixACLEntry{tag: aclMask, perm: groupClass, id: aclUndefinedID})
+	}
+
+	return completed
+}
+
+// validateACLPermissions reports an access ACL entry that contradicts the configured
+// permissions. The owner and other entries, and the mask or, without named entries and mask, the
+// owning group entry are the permission bits, so they cannot differ from them. Unknown values are
+// not checked.
+func validateACLPermissions(acl []LocalDirectoryACLEntry, permissions string) error {
+	mode, err := strconv.ParseUint(permissions, 8, 32)
+	if err != nil {
+		return nil
+	}
+
+	extended := false
+	for _, e := range acl {
+		if e.Tag.ValueString() == aclTagMask || e.Qualifier.ValueString() != "" || e.Qualifier.IsUnknown() {
+			extended = true
+		}
+	}
+
+	for _, e := range acl {
+		if e.Tag.IsUnknown() || e.Qualifier.IsUnknown() || e.Permissions.IsUnknown() || e.Qualifier.ValueString() != "" {
+			continue
+		}
+
+		var bits uint64
+		switch e.Tag.ValueString() {
+		case aclTagUser:
+			bits = mode >> 6 & 7
+		case aclTagGroup:
+			if extended {
+				continue
+			}
+			bits = mode >> 3 & 7
+		case aclTagMask:
+			bits = mode >> 3 & 7
+		case aclTagOther:
+			bits = mode & 7
+		default:
+			continue
+		}
+
+		perm, err := parseACLPermissions(e.Permissions.ValueString())
+		if err != nil {
+			continue
+		}
+		if uint64(perm) != bits {
+			return fmt.Errorf("the %s entry grants '%s', but permissions '%s' grant '%s'",
+				e.Tag.ValueString(), e.Permissions.ValueString(), permissions, formatACLPermissions(uint16(bits)))
+		}
+	}
+
+	return nil
+}
+
+// directoryACLModel converts the ACL read from a directory to the form used in the state. Entries
+// that match a configured entry keep its qualifier as written, so that a user name and its numeric
+// ID do not show up as drift. Unnamed entries that are not configured are derived from the
+// directory permissions and are left out.
+func directoryACLModel(actual []posixACLEntry, configured []LocalDirectoryACLEntry) []LocalDirectoryACLEntry {
+	result := []LocalDirectoryACLEntry{}
+
+	for _, entry := range actual {
+		var match *LocalDirectoryACLEntry
+		for i := range configured {
+			candidate, err := configured[i].posixACLEntry()
+			if err == nil && candidate.tag == entry.tag && candidate.id == entry.id {
+				match = &configured[i]
+				break
+			}
+		}
+
+		model := LocalDirectoryACLEntry{
+			Qualifier:   types.StringNull(),
+			Permissions: types.StringValue(formatACLPermissions(entry.perm)),
+		}
+		switch {
+		case match != nil:
+			model.Tag = match.Tag
+			model.Qualifier = match.Qualifier
+		case entry.tag == aclUser:
+			model.Tag = types.StringValue(aclTagUser)
+			model.Qualifier = types.StringValue(fsmeta.UserName(int(entry.id)))
+		case entry.tag == aclGroup:
+			model.Tag = types.StringValue(aclTagGroup)
+			model.Qualifier = types.StringValue(fsmeta.GroupName(int(entry.id)))
+		default:
+			continue
+		}
+
+		result = append(result, model)
+	}
+
+	return result
+}
+
+// applyDirectoryACL sets the access or default ACL of a directory. An empty ACL removes the
+// extended entries. With keepMode, the access ACL leaves the permission bits of mode unchanged.
+func applyDirectoryACL(path, name string, configured []LocalDirectoryACLEntry, mode os.FileMode, keepMode bool) error {
+	if !posixACLSupported {
+		return errPosixACLUnsupported
+	}
+	if len(configured) == 0 {
+		return removeXattr(path, name)
+	}
+
+	entries := make([]posixACLEntry, 0, len(configured))
+	for _, e := range configured {
+		entry, err := e.posixACLEntry()
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+	}
+
+	return setXattr(path, name, encodePosixACLEntries(completePosixACL(entries, mode, keepMode)))
+}
+
+// readDirectoryACL reads the access or default ACL of a directory. A directory without an access
+// ACL is described by its permissions alone.
+func readDirectoryACL(path, name string, configured []LocalDirectoryACLEntry, mode os.FileMode) ([]LocalDirectoryACLEntry, error) {
+	if !posixACLSupported {
+		return nil, errPosixACLUnsupported
+	}
+
+	var actual []posixACLEntry
+	encoded, err := getXattr(path, name)
+	switch {
+	case err == nil:
+		actual, err = decodePosixACL(encoded)
+		if err != nil {
+			return nil, err
+		}
+	case xattrNotFound(err):
+		if name == xattrACLAccess {
+			actual = completePosixACL(nil, mode, false)
+		}
+	default:
+		return nil, err
+	}
+
+	return directoryACLModel(actual, configured), nil
+}
+
+// applyDirectoryXattrs sets the planned extended attributes and removes the ones that were
+// previously managed but are no longer configured.
+func applyDirectoryXattrs(path string, planned, prior map[string]string) error {
+	for _, name := range sortedKeys(prior) {
+		if _, ok := planned[name]; ok {
+			continue
+		}
+		if err := removeXattr(path, name); err != nil {
+			return fmt.Errorf("failed to remove '%s': %w", name, err)
+		}
+	}
+
+	for _, name := range sortedKeys(planned) {
+		if err := setXattr(path, name, []byte(planned[name])); err != nil {
+			return fmt.Errorf("failed to set '%s': %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// readDirectoryXattrs reads the configured extended attributes. Attributes that no longer exist
+// are left out, other attributes of the directory are ignored.
+func readDirectoryXattrs(path string, configured map[string]string) (map[string]string, error) {
+	actual := map[string]string{}
+
+	for name := range configured {
+		value, err := getXattr(path, name)
+		if xattrNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read '%s': %w", name, err)
+		}
+		actual[name] = string(value)
+	}
+
+	return actual, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// applyDirectoryAttributes applies the configured ACLs and extended attributes to the directory.
+// Attributes the filesystem does not support produce a warning instead of an error.
+func applyDirectoryAttributes(ctx context.Context, data *LocalDirectory, prior *LocalDirectory, diagnostics *diag.Diagnostics) {
+	directoryPath := data.Path.ValueString()
+	info, err := os.Stat(directoryPath)
+	if err != nil {
+		diagnostics.AddError("Error Retrieving Directory Info", err.Error())
+		return
+	}
+
+	apply := func(attribute string, err error) bool {
+		if err == nil {
+			return true
+		}
+		if errors.Is(err, errPosixACLUnsupported) || xattrUnsupported(err) {
+			diagnostics.AddWarning(
+				"Extended Attributes Not Supported",
+				fmt.Sprintf("Skipping %s on '%s': %v", attribute, directoryPath, err),
+			)
+			return true
+		}
+		diagnostics.AddError(
+			"Error Setting Extended Attributes",
+			fmt.Sprintf("Failed to set %s on '%s': %v", attribute, directoryPath, err),
+		)
+		return false
+	}
+
+	// The configured permissions were applied before and are kept in the state, the mask must
+	// not change them
+	keepMode := data.Permissions.ValueString() != ""
+
+	// An ACL removed from the configuration is removed from the directory, which leaves the access
+	// ACL described by the permissions alone
+	acl, defaultACL := data.ACL, data.DefaultACL
+	if prior != nil && prior.ACL != nil && acl == nil {
+		acl = []LocalDirectoryACLEntry{}
+	}
+	if prior != nil && prior.DefaultACL != nil && defaultACL == nil {
+		defaultACL = []LocalDirectoryACLEntry{}
+	}
+	if acl != nil {
+		if !apply("acl", applyDirectoryACL(directoryPath, xattrACLAccess, acl, info.Mode(), keepMode)) {
+			return
+		}
+	}
+	if defaultACL != nil {
+		if !apply("default_acl", applyDirectoryACL(directoryPath, xattrACLDefault, defaultACL, info.Mode(), false)) {
+			return
+		}
+	}
+
+	var planned, previous map[string]string
+	diagnostics.Append(data.Xattrs.ElementsAs(ctx, &planned, false)...)
+	if prior != nil {
+		diagnostics.Append(prior.Xattrs.ElementsAs(ctx, &previous, false)...)
+	}
+	if diagnostics.HasError() {
+		return
+	}
+	if len(planned) > 0 || len(previous) > 0 {
+		if !apply("xattrs", applyDirectoryXattrs(directoryPath, planned, previous)) {
+			return
+		}
+	}
+
+	tflog.Debug(ctx, "Applied directory extended attributes", map[string]interface{}{
+		"path":   directoryPath,
+		"xattrs": len(planned),
+	})
+}
+
+// readDirectoryAttributes refreshes the configured ACLs and extended attributes from the
+// directory. When the filesystem does not support them the state is kept.
+func readDirectoryAttributes(ctx context.Context, data *LocalDirectory, mode os.FileMode, diagnostics *diag.Diagnostics) {
+	directoryPath := data.Path.ValueString()
+
+	read := func(attribute string, err error) bool {
+		if err == nil {
+			return true
+		}
+		if !errors.Is(err, errPosixACLUnsupported) && !xattrUnsupported(err) {
+			diagnostics.AddError(
+				"Error Reading Extended Attributes",
+				fmt.Sprintf("Failed to read %s of '%s': %v", attribute, directoryPath, err),
+			)
+		}
+		return false
+	}
+
+	if data.ACL != nil {
+		if acl, err := readDirectoryACL(directoryPath, xattrACLAccess, data.ACL, mode); read("acl", err) {
+			data.ACL = acl
+		}
+	}
+	if data.DefaultACL != nil {
+		if acl, err := readDirectoryACL(directoryPath, xattrACLDefault, data.DefaultACL, mode); read("default_acl", err) {
+			data.DefaultACL = acl
+		}
+	}
+
+	if data.Xattrs.IsNull() || data.Xattrs.IsUnknown() {
+		return
+	}
+	var configured map[string]string
+	diagnostics.Append(data.Xattrs.ElementsAs(ctx, &configured, false)...)
+	if diagnostics.HasError() {
+		return
+	}
+	if xattrs, err := readDirectoryXattrs(directoryPath, configured); read("xattrs", err) {
+		value, diags := types.MapValueFrom(ctx, types.StringType, xattrs)
+		diagnostics.Append(diags...)
+		data.Xattrs = value
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"terraform-provider-utilities/internal/fsmeta"
+)
+
+// directoryTreeEntries returns every entry below root, excluding root itself and the entries whose
+// path relative to root matches one of the exclude globs. Excluded directories are not descended into.
+func directoryTreeEntries(root string, exclude []string) ([]string, error) {
+	var entries []string
+
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == root {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		if matchAnyGlob(exclude, rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		entries = append(entries, p)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk directory '%s': %w", root, err)
+	}
+
+	return entries, nil
+}
+
+// countNonconformingEntries returns how many of the entries differ from the expected ownership.
+// Settings that are empty in ownership are not checked.
+func countNonconformingEntries(entries []string, ownership extractOwnership) int64 {
+	uid, gid := -1, -1
+	if ownership.Owner != "" {
+		if id, err := fsmeta.LookupUID(ownership.Owner); err == nil {
+			uid = id
+		}
+	}
+	if ownership.Group != "" {
+		if id, err := fsmeta.LookupGID(ownership.Group); err == nil {
+			gid = id
+		}
+	}
+
+	var count int64
+	for _, p := range entries {
+		info, err := os.Lstat(p)
+		if err != nil {
+			continue
+		}
+
+		conforming := true
+		if entryUID, entryGID, ok := fsmeta.Ownership(info); ok {
+			if (uid != -1 && entryUID != uid) || (gid != -1 && entryGID != gid) {
+				conforming = false
+			}
+		}
+
+		mode := fmt.Sprintf("%04o", info.Mode().Perm())
+		if info.IsDir() && ownership.DirMode != "" && mode != ownership.DirMode {
+			conforming = false
+		}
+		if info.Mode().IsRegular() && ownership.FileMode != "" && mode != ownership.FileMode {
+			conforming = false
+		}
+
+		if !conforming {
+			count++
+		}
+	}
+
+	return count
+}
@@ -0,0 +1,643 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"terraform-provider-utilities/internal/fsmeta"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// extractOwnership holds the ownership and modes applied to every extracted entry.
+type extractOwnership struct {
+	Owner    string
+	Group    string
+	FileMode string
+	DirMode  string
+}
+
+func newExtractOwnership(owner, group, fileMode, dirMode types.String) extractOwnership {
+	return extractOwnership{
+		Owner:    owner.ValueString(),
+		Group:    group.ValueString(),
+		FileMode: fileMode.ValueString(),
+		DirMode:  dirMode.ValueString(),
+	}
+}
+
+func (o extractOwnership) isSet() bool {
+	return o.Owner != "" || o.Group != "" || o.FileMode != "" || o.DirMode != ""
+}
+
+// extractedPaths returns every path the extraction created, including the destination if the resource created it.
+func extractedPaths(destination string, destinationCreated bool, createdFiles []string) []string {
+	if !destinationCreated {
+		return createdFiles
+	}
+
+	return append([]string{destination}, createdFiles...)
+}
+
+// extractLogSubsystem is the tflog subsystem used for per-entry extraction events.
+const extractLogSubsystem = "extract"
+
+// archiveResponseTimeout bounds the wait for the response to an archive download, so that a
+// stalled server does not hang the apply.
+const archiveResponseTimeout = 60 * time.Second
+
+// extractOptions holds the settings that change how archive entries are written.
+type extractOptions struct {
+	Rename entryRenamer
+	Report *extractReport
+	Xattrs *extractXattrs
+
+	// Password decrypts encrypted zip entries.
+	Password string
+	// FilenameEncoding decodes zip entry names that are not flagged as UTF-8.
+	FilenameEncoding string
+}
+
+// extractReport summarizes what an extraction did.
+type extractReport struct {
+	EntriesExtracted int64
+	BytesWritten     int64 // logical size, including holes in sparse files
+	PhysicalBytes    int64 // data actually written, excluding holes
+	SkippedEntries   int64
+	Duration         time.Duration
+
+	started time.Time
+}
+
+// newExtractReport starts a report and returns a context carrying the extraction log subsystem.
+func newExtractReport(ctx context.Context) (context.Context, *extractReport) {
+	return tflog.NewSubsystem(ctx, extractLogSubsystem), &extractReport{started: time.Now()}
+}
+
+// finish records the time elapsed since the report was started.
+func (r *extractReport) finish() {
+	r.Duration = time.Since(r.started)
+}
+
+// entryExtracted records an extracted entry with its logical size and the bytes physically
+// written, which are fewer for sparse files. The report may be nil.
+func (r *extractReport) entryExtracted(ctx context.Context, path string, logical, physical int64) {
+	tflog.SubsystemDebug(ctx, extractLogSubsystem, "Extracted entry", map[string]interface{}{
+		"path":           path,
+		"bytes":          logical,
+		"physical_bytes": physical,
+	})
+
+	if r != nil {
+		r.EntriesExtracted++
+		r.BytesWritten += logical
+		r.PhysicalBytes += physical
+	}
+}
+
+// entrySkipped records an entry that was not extracted. The report may be nil.
+func (r *extractReport) entrySkipped(ctx context.Context, name, reason string) {
+	tflog.SubsystemDebug(ctx, extractLogSubsystem, "Skipped entry", map[string]interface{}{
+		"name":   name,
+		"reason": reason,
+	})
+
+	if r != nil {
+		r.SkippedEntries++
+	}
+}
+
+// entryDestination returns the path an archive entry is extracted to. Entries that would end up
+// outside the destination directory, such as "../etc/passwd", are rejected.
+func entryDestination(destination, name string) (string, error) {
+	destPath := filepath.Join(destination, name)
+
+	rel, err := filepath.Rel(destination, destPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("entry '%s' is outside the destination directory '%s'", name, destination)
+	}
+
+	return destPath, nil
+}
+
+// prepareEntryPath makes sure that creating destPath cannot write outside destination: its parent
+// directory must resolve inside destination, and a symbolic link already at destPath, extracted
+// from an earlier entry or left by a previous extraction, is removed instead of followed.
+func prepareEntryPath(destination, destPath string) error {
+	if !isSubPath(destination, filepath.Dir(destPath)) {
+		return fmt.Errorf("entry '%s' is outside the destination directory '%s' through a symbolic link", destPath, destination)
+	}
+	if info, err := os.Lstat(destPath); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		return os.Remove(destPath)
+	}
+
+	return nil
+}
+
+// linkEntryTarget returns the target of a link entry. A symbolic link target must be relative and
+// stay inside the destination directory when resolved from the directory of the link. A hard link
+// target is the name of an earlier entry, renamed like it, and is returned as the path it was
+// extracted to.
+func linkEntryTarget(header *tar.Header, destination, destPath string, options extractOptions) (string, error) {
+	if header.Typeflag == tar.TypeLink {
+		name, ok := options.Rename.apply(header.Linkname)
+		if !ok {
+			return "", fmt.Errorf("hard link target '%s' is dropped by a rename rule", header.Linkname)
+		}
+		target, err := entryDestination(destination, name)
+		if err != nil {
+			return "", err
+		}
+		if !isSubPath(destination, filepath.Dir(target)) {
+			return "", fmt.Errorf("hard link target '%s' is outside the destination directory '%s' through a symbolic link", header.Linkname, destination)
+		}
+		return target, nil
+	}
+
+	target := filepath.FromSlash(header.Linkname)
+	if target == "" || filepath.IsAbs(target) {
+		return "", fmt.Errorf("symbolic link target '%s' is not a relative path", header.Linkname)
+	}
+	dir, err := filepath.Rel(destination, filepath.Dir(destPath))
+	if err != nil {
+		return "", err
+	}
+	if _, err := entryDestination(destination, filepath.Join(dir, target)); err != nil {
+		return "", fmt.Errorf("symbolic link target '%s' is outside the destination directory '%s'", header.Linkname, destination)
+	}
+
+	return target, nil
+}
+
+// extractLinkEntry creates the symbolic or hard link of an entry at destPath, replacing an entry
+// left by a previous extraction, and returns the number of bytes written. Hard links that carry
+// contents, as in cpio archives where the data comes with the last link, write them to the shared
+// file.
+func extractLinkEntry(header *tar.Header, reader io.Reader, destination, destPath, target string) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		return 0, err
+	}
+	if err := prepareEntryPath(destination, destPath); err != nil {
+		return 0, err
+	}
+	if info, err := os.Lstat(destPath); err == nil && !info.IsDir() {
+		if err := os.Remove(destPath); err != nil {
+			return 0, err
+		}
+	}
+
+	if header.Typeflag == tar.TypeSymlink {
+		return 0, os.Symlink(target, destPath)
+	}
+	if err := os.Link(target, destPath); err != nil {
+		return 0, err
+	}
+	if header.Size == 0 {
+		return 0, nil
+	}
+
+	file, err := os.OpenFile(destPath, os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = file.Close() }()
+
+	return io.Copy(file, reader)
+}
+
+// applyExtractOwnership changes the owner, group and mode of the extracted entries.
+func applyExtractOwnership(ctx context.Context, paths []string, ownership extractOwnership) error {
+	if !ownership.isSet() {
+		return nil
+	}
+
+	uid, gid := -1, -1
+	var err error
+	if ownership.Owner != "" {
+		if uid, err = fsmeta.LookupUID(ownership.Owner); err != nil {
+			return err
+		}
+	}
+	if ownership.Group != "" {
+		if gid, err = fsmeta.LookupGID(ownership.Group); err != nil {
+			return err
+		}
+	}
+
+	// For Windows, ownership cannot be changed
+	if runtime.GOOS == "windows" {
+		uid, gid = -1, -1
+	}
+
+	fileMode, err := parseExtractMode(ownership.FileMode)
+	if err != nil {
+		return err
+	}
+	dirMode, err := parseExtractMode(ownership.DirMode)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range paths {
+		info, err := os.Lstat(p)
+		if err != nil {
+			return fmt.Errorf("failed to stat '%s': %w", p, err)
+		}
+
+		if uid != -1 || gid != -1 {
+			if err := os.Lchown(p, uid, gid); err != nil {
+				return fmt.Errorf("failed to set ownership for '%s': %w", p, err)
+			}
+		}
+
+		switch {
+		case info.IsDir() && ownership.DirMode != "":
+			err = os.Chmod(p, dirMode)
+		case info.Mode().IsRegular() && ownership.FileMode != "":
+			err = os.Chmod(p, fileMode)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to set permissions for '%s': %w", p, err)
+		}
+	}
+
+	tflog.Debug(ctx, "Applied ownership to extracted entries", map[string]interface{}{
+		"entries":   len(paths),
+		"owner":     ownership.Owner,
+		"group":     ownership.Group,
+		"file_mode": ownership.FileMode,
+		"dir_mode":  ownership.DirMode,
+	})
+
+	return nil
+}
+
+// extractOwnershipDrift compares the extracted entries with the configured ownership and returns the
+// observed value of every setting that no longer matches. Settings without drift are left empty.
+func extractOwnershipDrift(paths []string, ownership extractOwnership) extractOwnership {
+	var drift extractOwnership
+
+	for _, p := range paths {
+		info, err := os.Lstat(p)
+		if err != nil {
+			continue
+		}
+
+		if uid, gid, ok := fsmeta.Ownership(info); ok {
+			if ownership.Owner != "" && drift.Owner == "" {
+				if name := fsmeta.UserName(uid); name != ownership.Owner {
+					drift.Owner = name
+				}
+			}
+			if ownership.Group != "" && drift.Group == "" {
+				if name := fsmeta.GroupName(gid); name != ownership.Group {
+					drift.Group = name
+				}
+			}
+		}
+
+		mode := fmt.Sprintf("%04o", info.Mode().Perm())
+		if info.IsDir() && ownership.DirMode != "" && drift.DirMode == "" && mode != ownership.DirMode {
+			drift.DirMode = mode
+		}
+		if info.Mode().IsRegular() && ownership.FileMode != "" && drift.FileMode == "" && mode != ownership.FileMode {
+			drift.FileMode = mode
+		}
+	}
+
+	return drift
+}
+
+func parseExtractMode(mode string) (os.FileMode, error) {
+	if mode == "" {
+		return 0, nil
+	}
+
+	value, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil {
+		return 0, fmt.Errorf("failed to convert permissions '%s': %w", mode, err)
+	}
+
+	return os.FileMode(value), nil
+}
+
+// extractTarget holds the state shared by the extraction resources that describes where the
+// entries were written and how they are managed afterwards.
+type extractTarget struct {
+	Destination        string
+	DestinationCreated bool
+	Ownership          extractOwnership
+	ManifestMode       string
+	ManifestPath       types.String
+	Manifest           extractManifest
+}
+
+// paths returns every path the extraction created, read from createdFiles.
+func (t extractTarget) paths(createdFiles []string) []string {
+	return extractedPaths(t.Destination, t.DestinationCreated, createdFiles)
+}
+
+func (t extractTarget) manifestPath() string {
+	return manifestPathFor(t.Destination, t.ManifestPath)
+}
+
+// archiveKind names the archive format of an extraction resource in its diagnostics.
+type archiveKind struct {
+	Name       string // summary prefix, such as "TarGz"
+	Noun       string // used in details, such as "TarGz file"
+	Extraction string // the extraction step, such as "TarGz extraction"
+}
+
+// extractArchive checks that source exists, creates the destination directory if needed and
+// calls extract to write the entries. It returns the created files and whether the destination
+// directory was created.
+func extractArchive(ctx context.Context, kind archiveKind, source, destination string, diagnostics *diag.Diagnostics, extract func(createdFiles *[]string) error) ([]string, bool, error) {
+	createdFiles := []string{}
+	destinationCreated := false
+
+	// Check if the context is already canceled
+	select {
+	case <-ctx.Done():
+		diagnostics.AddError("Operation Canceled", "Context was canceled before validation started.")
+		return nil, false, ctx.Err()
+	default:
+		// Continue execution
+	}
+
+	// Validate the source file
+	if _, err := os.Stat(source); os.IsNotExist(err) {
+		diagnostics.AddError(
+			"Source File Not Found",
+			fmt.Sprintf("The source file '%s' does not exist.", source),
+		)
+		return nil, false, fmt.Errorf("source file '%s' does not exist", source)
+	}
+
+	// Check if the destination directory exists
+	if _, err := os.Stat(destination); os.IsNotExist(err) {
+		select {
+		case <-ctx.Done():
+			diagnostics.AddError("Operation Canceled", "Context was canceled before creating the destination directory.")
+			return nil, false, ctx.Err()
+		default:
+			if err := os.MkdirAll(destination, 0755); err != nil {
+				diagnostics.AddError(
+					"Destination Directory Creation Failed",
+					fmt.Sprintf("Failed to create destination directory '%s': %v", destination, err),
+				)
+				return nil, false, fmt.Errorf("failed to create directory '%s': %w", destination, err)
+			}
+			destinationCreated = true
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+		diagnostics.AddError("Operation Canceled", fmt.Sprintf("Context was canceled before %s.", kind.Extraction))
+		return nil, false, ctx.Err()
+	default:
+		if err := extract(&createdFiles); err != nil {
+			diagnostics.AddError(
+				kind.Name+" Extraction Failed",
+				fmt.Sprintf("Error extracting %s from '%s' to '%s': %v", kind.Noun, source, destination, err),
+			)
+			return nil, false, err
+		}
+	}
+
+	return createdFiles, destinationCreated, nil
+}
+
+// downloadArchive downloads url to a temporary file named after pattern and verifies its detached
+// signature. The caller removes the returned file.
+func downloadArchive(ctx context.Context, kind archiveKind, url, pattern string, signature archiveSignature, diagnostics *diag.Diagnostics) (string, error) {
+	// Check if the context is already canceled
+	select {
+	case <-ctx.Done():
+		diagnostics.AddError("Operation Canceled", "Context was canceled before validation started.")
+		return "", ctx.Err()
+	default:
+		// Continue execution
+	}
+
+	tmpFile, err := downloadTempFile(ctx, url, pattern)
+	if err != nil {
+		diagnostics.AddError(
+			kind.Name+" Download Failed",
+			fmt.Sprintf("Error downloading the %s from '%s': %v", kind.Noun, url, err),
+		)
+		return "", err
+	}
+
+	// Verify the detached signature before extracting anything
+	if err := verifyArchiveSignature(ctx, tmpFile, signature); err != nil {
+		_ = os.Remove(tmpFile)
+		diagnostics.AddError(
+			"Signature Verification Failed",
+			fmt.Sprintf("The signature of the %s downloaded from '%s' could not be verified: %v", kind.Noun, url, err),
+		)
+		return "", err
+	}
+
+	return tmpFile, nil
+}
+
+// downloadTempFile downloads url to a temporary file named after pattern. The file is removed
+// when the download fails.
+func downloadTempFile(ctx context.Context, url, pattern string) (string, error) {
+	tmpFile, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", fmt.Errorf("error creating temporary file: %w", err)
+	}
+	defer func() { _ = tmpFile.Close() }()
+
+	if err := writeDownload(ctx, url, tmpFile); err != nil {
+		_ = os.Remove(tmpFile.Name())
+		return "", err
+	}
+
+	return tmpFile.Name(), nil
+}
+
+// writeDownload copies the body of url to w. The whole download is not bounded, as archives may
+// be large, but a server that does not start responding is given up on.
+func writeDownload(ctx context.Context, url string, w io.Writer) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = archiveResponseTimeout
+	client := &http.Client{Transport: transport}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error making HTTP request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download file, HTTP status: %s", resp.Status)
+	}
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("error writing the downloaded file to temporary file: %w", err)
+	}
+
+	return nil
+}
+
+// finishExtraction extracts the archives found among the created files when nested extraction
+// is configured, completes the report and applies the target with applyExtractTarget.
+func finishExtraction(ctx context.Context, target extractTarget, createdFiles []string, nested *ExtractNested, options extractOptions, diagnostics *diag.Diagnostics) (extractManifest, bool) {
+	createdFiles, err := extractNestedArchives(ctx, target.Destination, createdFiles, nested, options, diagnostics)
+	if err != nil {
+		diagnostics.AddError(
+			"Nested Extraction Failed",
+			fmt.Sprintf("Error extracting nested archives in '%s': %v", target.Destination, err),
+		)
+		return extractManifest{}, false
+	}
+	options.Report.finish()
+
+	return applyExtractTarget(ctx, target, createdFiles, options.Xattrs, diagnostics)
+}
+
+// applyExtractTarget applies ownership and permissions to every extracted entry, restores the
+// recorded extended attributes and records the created files in the configured manifest.
+func applyExtractTarget(ctx context.Context, target extractTarget, createdFiles []string, xattrs *extractXattrs, diagnostics *diag.Diagnostics) (extractManifest, bool) {
+	if err := applyExtractOwnership(ctx, target.paths(createdFiles), target.Ownership); err != nil {
+		diagnostics.AddError(
+			"Ownership Change Failed",
+			fmt.Sprintf("Error applying ownership to files extracted to '%s': %v", target.Destination, err),
+		)
+		return extractManifest{}, false
+	}
+
+	// Restore extended attributes now that ownership changes can no longer clear them
+	restoreExtractXattrs(ctx, xattrs, diagnostics)
+
+	manifest, err := saveExtractManifest(target.ManifestMode, target.manifestPath(), createdFiles)
+	if err != nil {
+		diagnostics.AddError(
+			"Manifest Write Failed",
+			fmt.Sprintf("Error recording the files extracted to '%s': %v", target.Destination, err),
+		)
+		return extractManifest{}, false
+	}
+
+	return manifest, true
+}
+
+// sourceHashDrift recalculates the hash of a local source and returns the hash to store, warning
+// when it changed. Downloaded archives are not checked.
+func sourceHashDrift(source, url, fileHash types.String, noun string, diagnostics *diag.Diagnostics) types.String {
+	if !url.IsNull() || fileHash.IsNull() {
+		return fileHash
+	}
+
+	hash, err := calculateFileHash(source.ValueString())
+	if err != nil {
+		diagnostics.AddError(
+			"File Hash Calculation Failed",
+			fmt.Sprintf("Unable to calculate hash for source file: %s", err),
+		)
+		return fileHash
+	}
+	if hash != fileHash.ValueString() {
+		diagnostics.AddWarning(
+			"File Hash Mismatch Detected",
+			fmt.Sprintf("The hash of the %s has changed, marking the resource for update.", noun),
+		)
+	}
+
+	return types.StringValue(hash)
+}
+
+// extractTargetDrift reads the manifest and the extracted entries of a target. A manifest that no
+// longer matches the state gets an empty digest, which plans a new extraction, and a state written
+// before the digest and count were recorded is backfilled. It returns the manifest and the
+// observed value of every ownership setting that drifted.
+func extractTargetDrift(ctx context.Context, target extractTarget, diagnostics *diag.Diagnostics) (extractManifest, extractOwnership) {
+	manifest := target.Manifest
+
+	createdFiles, err := readExtractManifest(ctx, target.ManifestMode, target.manifestPath(), manifest)
+	if err != nil {
+		manifest.Digest = types.StringValue("")
+		diagnostics.AddWarning(
+			"Manifest Drift Detected",
+			fmt.Sprintf("The manifest of extracted files no longer matches the state, marking the resource for update: %v", err),
+		)
+	}
+
+	if err == nil && manifest.Digest.IsNull() {
+		if backfilled, err := newExtractManifest(target.ManifestMode, createdFiles); err == nil {
+			manifest.Digest = backfilled.Digest
+			manifest.FileCount = backfilled.FileCount
+		}
+	}
+
+	drift := extractOwnershipDrift(target.paths(createdFiles), target.Ownership)
+	if drift.isSet() {
+		diagnostics.AddWarning(
+			"Ownership Drift Detected",
+			"The ownership or permissions of the extracted files have changed, marking the resource for update.",
+		)
+	}
+
+	return manifest, drift
+}
+
+// deleteExtractTarget deletes the files recorded in the manifest of a target, children before
+// their parents, and the sidecar manifest. Failures are reported as warnings.
+func deleteExtractTarget(ctx context.Context, target extractTarget, diagnostics *diag.Diagnostics) {
+	createdFiles, err := readExtractManifest(ctx, target.ManifestMode, target.manifestPath(), target.Manifest)
+	if err != nil {
+		diagnostics.AddWarning(
+			"Manifest Read Failed",
+			fmt.Sprintf("Could not read the list of extracted files, no files were deleted: %v", err),
+		)
+	}
+
+	for i := len(createdFiles) - 1; i >= 0; i-- {
+		file := createdFiles[i]
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			diagnostics.AddWarning(
+				"File Deletion Failed",
+				fmt.Sprintf("Could not delete file '%s': %v", file, err),
+			)
+		}
+	}
+
+	if target.ManifestMode == manifestModeSidecar {
+		if err := os.Remove(target.manifestPath()); err != nil && !os.IsNotExist(err) {
+			diagnostics.AddWarning(
+				"File Deletion Failed",
+				fmt.Sprintf("Could not delete manifest '%s': %v", target.manifestPath(), err),
+			)
+		}
+	}
+}
+
+// driftedValue returns the observed value of a setting that drifted, or the stored value.
+func driftedValue(stored types.String, observed string) types.String {
+	if observed != "" {
+		return types.StringValue(observed)
+	}
+
+	return stored
+}
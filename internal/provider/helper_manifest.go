@@ -0,0 +1,160 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	manifestModeState      = "state"
+	manifestModeSidecar    = "sidecar"
+	manifestModeCompressed = "compressed"
+
+	// defaultManifestFile is the sidecar manifest name used when manifest_path is not set.
+	defaultManifestFile = ".utilities-manifest"
+)
+
+// extractManifest is the state representation of the files created by an extraction.
+type extractManifest struct {
+	CreatedFiles types.List
+	Compressed   types.String
+	Digest       types.String
+	FileCount    types.Int64
+}
+
+// manifestPathFor returns the sidecar manifest path, defaulting to a file inside the destination.
+func manifestPathFor(destination string, manifestPath types.String) string {
+	if manifestPath.ValueString() != "" {
+		return manifestPath.ValueString()
+	}
+
+	return filepath.Join(destination, defaultManifestFile)
+}
+
+// manifestContent serializes the created files, one path per line.
+func manifestContent(createdFiles []string) []byte {
+	var buf bytes.Buffer
+	for _, file := range createdFiles {
+		buf.WriteString(file)
+		buf.WriteByte('\n')
+	}
+
+	return buf.Bytes()
+}
+
+func parseManifestContent(content []byte) []string {
+	createdFiles := []string{}
+	for _, line := range strings.Split(string(content), "\n") {
+		if line != "" {
+			createdFiles = append(createdFiles, line)
+		}
+	}
+
+	return createdFiles
+}
+
+func manifestDigest(content []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(content))
+}
+
+// newExtractManifest builds the state representation of the created files for the given mode.
+// Only the state mode keeps the full list in created_files.
+func newExtractManifest(mode string, createdFiles []string) (extractManifest, error) {
+	content := manifestContent(createdFiles)
+	manifest := extractManifest{
+		CreatedFiles: types.ListNull(types.StringType),
+		Compressed:   types.StringNull(),
+		Digest:       types.StringValue(manifestDigest(content)),
+		FileCount:    types.Int64Value(int64(len(createdFiles))),
+	}
+
+	switch mode {
+	case manifestModeSidecar:
+		// Only the digest and count are kept in state
+	case manifestModeCompressed:
+		var buf bytes.Buffer
+		gzipWriter := gzip.NewWriter(&buf)
+		if _, err := gzipWriter.Write(content); err != nil {
+			return manifest, fmt.Errorf("failed to compress manifest: %w", err)
+		}
+		if err := gzipWriter.Close(); err != nil {
+			return manifest, fmt.Errorf("failed to compress manifest: %w", err)
+		}
+		manifest.Compressed = types.StringValue(base64.StdEncoding.EncodeToString(buf.Bytes()))
+	default:
+		attrList := []attr.Value{}
+		for _, file := range createdFiles {
+			attrList = append(attrList, types.StringValue(file))
+		}
+		manifest.CreatedFiles = types.ListValueMust(types.StringType, attrList)
+	}
+
+	return manifest, nil
+}
+
+// saveExtractManifest builds the manifest and writes the sidecar file when required.
+func saveExtractManifest(mode, manifestPath string, createdFiles []string) (extractManifest, error) {
+	manifest, err := newExtractManifest(mode, createdFiles)
+	if err != nil {
+		return manifest, err
+	}
+
+	if mode == manifestModeSidecar {
+		if err := os.WriteFile(manifestPath, manifestContent(createdFiles), 0644); err != nil {
+			return manifest, fmt.Errorf("failed to write manifest '%s': %w", manifestPath, err)
+		}
+	}
+
+	return manifest, nil
+}
+
+// readExtractManifest returns the created files recorded by the manifest. A sidecar manifest
+// must still match the digest kept in state.
+func readExtractManifest(ctx context.Context, mode, manifestPath string, manifest extractManifest) ([]string, error) {
+	switch mode {
+	case manifestModeSidecar:
+		content, err := os.ReadFile(manifestPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manifest '%s': %w", manifestPath, err)
+		}
+		if digest := manifestDigest(content); digest != manifest.Digest.ValueString() {
+			return nil, fmt.Errorf("manifest '%s' has digest '%s', expected '%s'", manifestPath, digest, manifest.Digest.ValueString())
+		}
+		return parseManifestContent(content), nil
+	case manifestModeCompressed:
+		data, err := base64.StdEncoding.DecodeString(manifest.Compressed.ValueString())
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode manifest: %w", err)
+		}
+		gzipReader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress manifest: %w", err)
+		}
+		defer func() { _ = gzipReader.Close() }()
+		content, err := io.ReadAll(gzipReader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress manifest: %w", err)
+		}
+		return parseManifestContent(content), nil
+	default:
+		createdFiles := []string{}
+		if diags := manifest.CreatedFiles.ElementsAs(ctx, &createdFiles, false); diags.HasError() {
+			return nil, fmt.Errorf("failed to read created_files from state")
+		}
+		return createdFiles, nil
+	}
+}
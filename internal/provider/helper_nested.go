@@ -0,0 +1,198 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	nestedTargetSibling = "sibling"
+	nestedTargetInPlace = "in_place"
+
+	defaultNestedMaxDepth = 1
+)
+
+// ExtractNested configures the extraction of archives found among the extracted files.
+type ExtractNested struct {
+	Patterns types.List   `tfsdk:"patterns"`
+	MaxDepth types.Int64  `tfsdk:"max_depth"`
+	Target   types.String `tfsdk:"target"`
+}
+
+// extractNestedSchema returns the schema of the extract_nested attribute shared by the extraction resources.
+func extractNestedSchema() schema.SingleNestedAttribute {
+	return schema.SingleNestedAttribute{
+		Optional:    true,
+		Description: "Extracts archives found inside the extracted files. Supported inner archives are `.zip`, `.tar.gz`, `.tgz` and `.tar`. Their contents are added to `created_files`, which is then only known after apply.",
+		Attributes: map[string]schema.Attribute{
+			"patterns": schema.ListAttribute{
+				ElementType: types.StringType,
+				Required:    true,
+				Description: "Glob patterns matched against the path of each extracted file relative to the destination, e.g. `**/*.tar.gz`. A `**` segment matches any number of directories.",
+				Validators: []validator.List{
+					listvalidator.SizeAtLeast(1),
+				},
+			},
+			"max_depth": schema.Int64Attribute{
+				Optional:    true,
+				Description: "The number of nesting levels to extract. Defaults to 1, which only extracts archives contained in the outer archive.",
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"target": schema.StringAttribute{
+				Optional:    true,
+				Description: "Where inner archives are extracted: `sibling` (default) extracts into a directory next to the archive named after it without its extension, `in_place` extracts into the directory containing the archive.",
+				Validators: []validator.String{
+					stringvalidator.OneOf(nestedTargetSibling, nestedTargetInPlace),
+				},
+			},
+		},
+	}
+}
+
+// equal reports whether two nested configurations are the same.
+func (n *ExtractNested) equal(other *ExtractNested) bool {
+	if n == nil || other == nil {
+		return n == other
+	}
+
+	return n.Patterns.Equal(other.Patterns) && n.MaxDepth.Equal(other.MaxDepth) && n.Target.Equal(other.Target)
+}
+
+// nestedArchiveExtension returns the archive extension of name, or an empty string when the
+// file is not a supported archive.
+func nestedArchiveExtension(name string) string {
+	lower := strings.ToLower(name)
+	for _, ext := range []string{".tar.gz", ".tgz", ".tar", ".zip"} {
+		if strings.HasSuffix(lower, ext) {
+			return name[len(name)-len(ext):]
+		}
+	}
+
+	return ""
+}
+
+// nestedArchiveTarget returns the directory an inner archive is extracted into.
+func nestedArchiveTarget(archive, target string) string {
+	if target == nestedTargetInPlace {
+		return filepath.Dir(archive)
+	}
+
+	return strings.TrimSuffix(archive, nestedArchiveExtension(archive))
+}
+
+// extractNestedArchive extracts an inner archive based on its extension. Rename rules only apply
+// to the entries of the outer archive.
+func extractNestedArchive(ctx context.Context, archive, destination string, options extractOptions, diagnostics *diag.Diagnostics, createdFiles *[]string) error {
+	switch strings.ToLower(nestedArchiveExtension(archive)) {
+	case ".zip":
+		return extractZipFile(ctx, archive, destination, options, diagnostics, createdFiles)
+	case ".tar":
+		return extractTarFile(ctx, archive, destination, options, diagnostics, createdFiles)
+	default:
+		return extractTarGzFile(ctx, archive, destination, options, diagnostics, createdFiles)
+	}
+}
+
+// extractNestedArchives extracts the archives among createdFiles that match the configured patterns,
+// then the archives found inside those, up to max_depth levels. It returns createdFiles followed by
+// every path created by the inner extractions.
+func extractNestedArchives(ctx context.Context, destination string, createdFiles []string, nested *ExtractNested, options extractOptions, diagnostics *diag.Diagnostics) ([]string, error) {
+	if nested == nil {
+		return createdFiles, nil
+	}
+
+	var patterns []string
+	if diags := nested.Patterns.ElementsAs(ctx, &patterns, false); diags.HasError() {
+		diagnostics.Append(diags...)
+		return nil, fmt.Errorf("failed to read extract_nested patterns")
+	}
+
+	maxDepth := int64(defaultNestedMaxDepth)
+	if !nested.MaxDepth.IsNull() {
+		maxDepth = nested.MaxDepth.ValueInt64()
+	}
+
+	target := nestedTargetSibling
+	if !nested.Target.IsNull() {
+		target = nested.Target.ValueString()
+	}
+
+	seen := make(map[string]bool, len(createdFiles))
+	for _, file := range createdFiles {
+		seen[file] = true
+	}
+	result := append([]string{}, createdFiles...)
+
+	current := createdFiles
+	for depth := int64(1); depth <= maxDepth && len(current) > 0; depth++ {
+		var next []string
+
+		for _, archive := range current {
+			rel, err := filepath.Rel(destination, archive)
+			if err != nil || !matchAnyGlob(patterns, rel) || nestedArchiveExtension(archive) == "" {
+				continue
+			}
+
+			info, err := os.Lstat(archive)
+			if err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+
+			// Inner archives get the same safety checks as the outer one, relative to their target directory
+			targetDir := nestedArchiveTarget(archive, target)
+			if _, err := os.Stat(targetDir); os.IsNotExist(err) {
+				if err := os.MkdirAll(targetDir, 0755); err != nil {
+					diagnostics.AddError(
+						"Directory Creation Failed",
+						fmt.Sprintf("Failed to create directory '%s': %v", targetDir, err),
+					)
+					return nil, err
+				}
+				seen[targetDir] = true
+				result = append(result, targetDir)
+			}
+
+			var innerFiles []string
+			if err := extractNestedArchive(ctx, archive, targetDir, extractOptions{Report: options.Report, Xattrs: options.Xattrs}, diagnostics, &innerFiles); err != nil {
+				return nil, fmt.Errorf("failed to extract nested archive '%s': %w", archive, err)
+			}
+
+			tflog.Debug(ctx, "Extracted nested archive", map[string]interface{}{
+				"archive":     archive,
+				"destination": targetDir,
+				"depth":       depth,
+				"entries":     len(innerFiles),
+			})
+
+			for _, file := range innerFiles {
+				if seen[file] {
+					continue
+				}
+				seen[file] = true
+				result = append(result, file)
+				next = append(next, file)
+			}
+		}
+
+		current = next
+	}
+
+	return result, nil
+}
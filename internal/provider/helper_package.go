@@ -0,0 +1,407 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	packageFormatAuto = "auto"
+	packageFormatDeb  = "deb"
+	packageFormatRPM  = "rpm"
+	packageFormatCpio = "cpio"
+
+	arMagic = "!<arch>\n"
+
+	// Header tags read from RPM packages
+	rpmTagName              = 1000
+	rpmTagVersion           = 1001
+	rpmTagRelease           = 1002
+	rpmTagArch              = 1022
+	rpmTagRequireName       = 1049
+	rpmTagPayloadCompressor = 1125
+
+	rpmTypeString      = 6
+	rpmTypeStringArray = 8
+	rpmTypeI18NString  = 9
+
+	// rpmMaxHeaderSize bounds the header read from an untrusted package.
+	rpmMaxHeaderSize = 256 * 1024 * 1024
+)
+
+var (
+	rpmLeadMagic   = []byte{0xed, 0xab, 0xee, 0xdb}
+	rpmHeaderMagic = []byte{0x8e, 0xad, 0xe8}
+)
+
+// packageMetadata holds the metadata read from a package.
+type packageMetadata struct {
+	Name         string
+	Version      string
+	Arch         string
+	Dependencies []string
+}
+
+// packageEntryFunc is called for every entry of a package payload with a reader for its contents.
+type packageEntryFunc func(header *tar.Header, reader io.Reader) error
+
+// detectPackageFormat determines the format of a package from its leading bytes.
+func detectPackageFormat(source string) (string, error) {
+	file, err := os.Open(source)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = file.Close() }()
+
+	magic := make([]byte, 8)
+	n, _ := io.ReadFull(file, magic)
+	magic = magic[:n]
+
+	switch {
+	case bytes.HasPrefix(magic, []byte(arMagic)):
+		return packageFormatDeb, nil
+	case bytes.HasPrefix(magic, rpmLeadMagic):
+		return packageFormatRPM, nil
+	case bytes.HasPrefix(magic, []byte(cpioMagicNewc)), bytes.HasPrefix(magic, []byte(cpioMagicCRC)), bytes.HasPrefix(magic, []byte(cpioMagicOdc)):
+		return packageFormatCpio, nil
+	default:
+		return "", fmt.Errorf("'%s' is not a deb, rpm or cpio file", source)
+	}
+}
+
+// walkPackage reads the metadata of a package and calls fn for every entry of its payload.
+// A nil fn only reads the metadata.
+func walkPackage(source, format string, fn packageEntryFunc) (packageMetadata, error) {
+	if format == "" || format == packageFormatAuto {
+		var err error
+		if format, err = detectPackageFormat(source); err != nil {
+			return packageMetadata{}, err
+		}
+	}
+
+	file, err := os.Open(source)
+	if err != nil {
+		return packageMetadata{}, err
+	}
+	defer func() { _ = file.Close() }()
+
+	reader := bufio.NewReader(file)
+	switch format {
+	case packageFormatDeb:
+		return walkDeb(reader, fn)
+	case packageFormatRPM:
+		return walkRPM(reader, fn)
+	case packageFormatCpio:
+		if fn == nil {
+			return packageMetadata{}, nil
+		}
+		return packageMetadata{}, walkCpio(reader, fn)
+	default:
+		return packageMetadata{}, fmt.Errorf("unsupported package format '%s'", format)
+	}
+}
+
+func walkCpio(r io.Reader, fn packageEntryFunc) error {
+	cpio := newCpioReader(r)
+	for {
+		header, err := cpio.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(header, cpio); err != nil {
+			return err
+		}
+	}
+}
+
+func walkTar(r io.Reader, fn packageEntryFunc) error {
+	tarReader := tar.NewReader(r)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(header, tarReader); err != nil {
+			return err
+		}
+	}
+}
+
+// walkDeb reads a Debian package: an ar archive holding control.tar.* and data.tar.* members.
+func walkDeb(r io.Reader, fn packageEntryFunc) (packageMetadata, error) {
+	var metadata packageMetadata
+
+	magic := make([]byte, len(arMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || string(magic) != arMagic {
+		return metadata, fmt.Errorf("invalid deb archive header")
+	}
+
+	foundData := false
+	for {
+		name, size, err := readArHeader(r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return metadata, err
+		}
+
+		member := io.LimitReader(r, size)
+		switch {
+		case strings.HasPrefix(name, "control.tar"):
+			if metadata, err = readDebControl(member, compressionFromExtension(name)); err != nil {
+				return metadata, err
+			}
+		case strings.HasPrefix(name, "data.tar") && fn != nil:
+			decompressed, err := newDecompressReader(member, compressionFromExtension(name))
+			if err != nil {
+				return metadata, fmt.Errorf("failed to decompress '%s': %w", name, err)
+			}
+			err = walkTar(decompressed, fn)
+			_ = decompressed.Close()
+			if err != nil {
+				return metadata, fmt.Errorf("failed to read '%s': %w", name, err)
+			}
+			foundData = true
+		}
+
+		// Only the control member is needed when reading the metadata
+		if fn == nil && metadata.Name != "" {
+			return metadata, nil
+		}
+
+		// Skip the rest of the member and the padding to an even offset
+		if _, err := io.Copy(io.Discard, member); err != nil {
+			return metadata, unexpectedEOF(err)
+		}
+		if size%2 == 1 {
+			if _, err := io.CopyN(io.Discard, r, 1); err != nil && err != io.EOF {
+				return metadata, err
+			}
+		}
+	}
+
+	if fn != nil && !foundData {
+		return metadata, fmt.Errorf("deb archive has no data.tar member")
+	}
+
+	return metadata, nil
+}
+
+// readArHeader reads the 60-byte header of the next ar member.
+func readArHeader(r io.Reader) (string, int64, error) {
+	header := make([]byte, 60)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return "", 0, err
+	}
+	if string(header[58:60]) != "`\n" {
+		return "", 0, fmt.Errorf("invalid ar member header")
+	}
+
+	name := strings.TrimSuffix(strings.TrimSpace(string(header[0:16])), "/")
+	size, err := strconv.ParseInt(strings.TrimSpace(string(header[48:58])), 10, 64)
+	if err != nil || size < 0 {
+		return "", 0, fmt.Errorf("invalid size for ar member '%s'", name)
+	}
+
+	return name, size, nil
+}
+
+// readDebControl parses the control file of a Debian package.
+func readDebControl(r io.Reader, compression string) (packageMetadata, error) {
+	var metadata packageMetadata
+
+	decompressed, err := newDecompressReader(r, compression)
+	if err != nil {
+		return metadata, fmt.Errorf("failed to decompress control archive: %w", err)
+	}
+	defer func() { _ = decompressed.Close() }()
+
+	var control []byte
+	err = walkTar(decompressed, func(header *tar.Header, reader io.Reader) error {
+		if path.Clean(header.Name) != "control" {
+			return nil
+		}
+		var readErr error
+		control, readErr = io.ReadAll(io.LimitReader(reader, 1024*1024))
+		return readErr
+	})
+	if err != nil {
+		return metadata, fmt.Errorf("failed to read control archive: %w", err)
+	}
+	if control == nil {
+		return metadata, fmt.Errorf("control archive has no control file")
+	}
+
+	fields := map[string]string{}
+	key := ""
+	for _, line := range strings.Split(string(control), "\n") {
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && key != "" {
+			fields[key] += "\n" + strings.TrimSpace(line)
+			continue
+		}
+		if name, value, found := strings.Cut(line, ":"); found {
+			key = strings.TrimSpace(name)
+			fields[key] = strings.TrimSpace(value)
+		}
+	}
+
+	metadata.Name = fields["Package"]
+	metadata.Version = fields["Version"]
+	metadata.Arch = fields["Architecture"]
+	metadata.Dependencies = []string{}
+	for _, dependency := range strings.Split(fields["Depends"], ",") {
+		if dependency = strings.TrimSpace(dependency); dependency != "" {
+			metadata.Dependencies = append(metadata.Dependencies, dependency)
+		}
+	}
+
+	return metadata, nil
+}
+
+// walkRPM reads an RPM package: the lead, the signature header, the main header and the
+// compressed cpio payload.
+func walkRPM(r io.Reader, fn packageEntryFunc) (packageMetadata, error) {
+	var metadata packageMetadata
+
+	lead := make([]byte, 96)
+	if _, err := io.ReadFull(r, lead); err != nil || !bytes.HasPrefix(lead, rpmLeadMagic) {
+		return metadata, fmt.Errorf("invalid rpm lead")
+	}
+
+	// The signature header is padded to a multiple of eight bytes
+	if _, err := readRPMHeader(r, true); err != nil {
+		return metadata, fmt.Errorf("failed to read rpm signature header: %w", err)
+	}
+
+	tags, err := readRPMHeader(r, false)
+	if err != nil {
+		return metadata, fmt.Errorf("failed to read rpm header: %w", err)
+	}
+
+	first := func(tag int32) string {
+		if values := tags[tag]; len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+
+	metadata.Name = first(rpmTagName)
+	metadata.Version = first(rpmTagVersion)
+	if release := first(rpmTagRelease); release != "" {
+		metadata.Version += "-" + release
+	}
+	metadata.Arch = first(rpmTagArch)
+	metadata.Dependencies = []string{}
+	seen := map[string]bool{}
+	for _, dependency := range tags[rpmTagRequireName] {
+		// Skip the internal rpmlib() capabilities
+		if strings.HasPrefix(dependency, "rpmlib(") || seen[dependency] {
+			continue
+		}
+		seen[dependency] = true
+		metadata.Dependencies = append(metadata.Dependencies, dependency)
+	}
+
+	if fn == nil {
+		return metadata, nil
+	}
+
+	compression := first(rpmTagPayloadCompressor)
+	if compression == "" {
+		compression = compressionGzip
+	}
+
+	payload, err := newDecompressReader(r, compression)
+	if err != nil {
+		return metadata, fmt.Errorf("failed to decompress rpm payload: %w", err)
+	}
+	defer func() { _ = payload.Close() }()
+
+	if err := walkCpio(payload, fn); err != nil {
+		return metadata, fmt.Errorf("failed to read rpm payload: %w", err)
+	}
+
+	return metadata, nil
+}
+
+// readRPMHeader reads an RPM header structure and returns its string values by tag.
+func readRPMHeader(r io.Reader, padded bool) (map[int32][]string, error) {
+	intro := make([]byte, 16)
+	if _, err := io.ReadFull(r, intro); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if !bytes.HasPrefix(intro, rpmHeaderMagic) {
+		return nil, fmt.Errorf("invalid header magic")
+	}
+
+	count := binary.BigEndian.Uint32(intro[8:12])
+	size := binary.BigEndian.Uint32(intro[12:16])
+	if uint64(count)*16+uint64(size) > rpmMaxHeaderSize {
+		return nil, fmt.Errorf("header size exceeds %d bytes", rpmMaxHeaderSize)
+	}
+
+	index := make([]byte, count*16)
+	if _, err := io.ReadFull(r, index); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	store := make([]byte, size)
+	if _, err := io.ReadFull(r, store); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if padded {
+		if pad := (8 - size%8) % 8; pad > 0 {
+			if _, err := io.CopyN(io.Discard, r, int64(pad)); err != nil {
+				return nil, unexpectedEOF(err)
+			}
+		}
+	}
+
+	tags := map[int32][]string{}
+	for i := uint32(0); i < count; i++ {
+		entry := index[i*16 : i*16+16]
+		tag := int32(binary.BigEndian.Uint32(entry[0:4]))
+		kind := binary.BigEndian.Uint32(entry[4:8])
+		offset := binary.BigEndian.Uint32(entry[8:12])
+		values := binary.BigEndian.Uint32(entry[12:16])
+
+		if kind != rpmTypeString && kind != rpmTypeStringArray && kind != rpmTypeI18NString {
+			continue
+		}
+		if kind == rpmTypeString {
+			values = 1
+		}
+		if offset >= size {
+			return nil, fmt.Errorf("tag %d points outside the header", tag)
+		}
+
+		data := store[offset:]
+		for j := uint32(0); j < values; j++ {
+			end := bytes.IndexByte(data, 0)
+			if end < 0 {
+				return nil, fmt.Errorf("tag %d has an unterminated string", tag)
+			}
+			tags[tag] = append(tags[tag], string(data[:end]))
+			data = data[end+1:]
+		}
+	}
+
+	return tags, nil
+}
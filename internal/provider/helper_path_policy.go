@@ -0,0 +1,157 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// pathPolicy decides which paths resources may modify. Protected paths are never changed or
+// deleted, and when allowed roots are configured every managed path must be inside one of them.
+// Both lists accept glob patterns, where a "**" segment matches any number of directories.
+type pathPolicy struct {
+	ProtectedPaths []string
+	AllowedRoots   []string
+}
+
+// newPathPolicy returns a policy protecting the built-in system paths and the given paths.
+func newPathPolicy(protected, allowedRoots []string) *pathPolicy {
+	return &pathPolicy{
+		ProtectedPaths: append(append([]string{}, protectedPaths...), protected...),
+		AllowedRoots:   allowedRoots,
+	}
+}
+
+// providerPathPolicy returns the policy configured on the provider, or the default policy when the
+// provider has not been configured.
+func providerPathPolicy(providerData any) *pathPolicy {
+	if policy, ok := providerData.(*pathPolicy); ok && policy != nil {
+		return policy
+	}
+
+	return newPathPolicy(nil, nil)
+}
+
+// isProtected reports whether path, once cleaned and with symbolic links evaluated, is one of the
+// protected paths.
+func (p *pathPolicy) isProtected(path string) bool {
+	return p.protects(path, resolvePath(path))
+}
+
+// isProtectedLink is isProtected for a symbolic link, which is matched where it is located rather
+// than where it points.
+func (p *pathPolicy) isProtectedLink(path string) bool {
+	return p.protects(path, resolveLinkPath(path))
+}
+
+func (p *pathPolicy) protects(path, resolved string) bool {
+	return matchPathPatterns(p.ProtectedPaths, []string{cleanAbsPath(path), resolved}, false)
+}
+
+// isAllowed reports whether path is inside one of the allowed roots. Only the resolved form is
+// considered, so that a symbolic link cannot point outside the roots. Every path is allowed when no
+// roots are configured.
+func (p *pathPolicy) isAllowed(path string) bool {
+	return p.allows(resolvePath(path))
+}
+
+func (p *pathPolicy) allows(resolved string) bool {
+	if len(p.AllowedRoots) == 0 {
+		return true
+	}
+
+	return matchPathPatterns(p.AllowedRoots, []string{resolved}, true)
+}
+
+// checkAllowed returns an error if path must not be managed: either it is outside the allowed
+// roots, or it is protected.
+func (p *pathPolicy) checkAllowed(path string) error {
+	return p.check(path, resolvePath(path))
+}
+
+// checkAllowedLink is checkAllowed for a symbolic link, which is checked where it is located
+// rather than where it points.
+func (p *pathPolicy) checkAllowedLink(path string) error {
+	return p.check(path, resolveLinkPath(path))
+}
+
+func (p *pathPolicy) check(path, resolved string) error {
+	if !p.allows(resolved) {
+		return fmt.Errorf("path '%s' is outside the allowed roots %s", path, strings.Join(p.AllowedRoots, ", "))
+	}
+	if p.protects(path, resolved) {
+		return fmt.Errorf("path '%s' is protected", path)
+	}
+
+	return nil
+}
+
+// matchPathPatterns reports whether one of the candidate paths matches one of the patterns. With
+// subtree set, paths below a matching pattern match too.
+func matchPathPatterns(patterns, candidates []string, subtree bool) bool {
+	for _, pattern := range patterns {
+		forms := []string{filepath.Clean(pattern)}
+		// Literal patterns are compared in their resolved form as well, e.g. "/bin" on systems
+		// where it links to "/usr/bin"
+		if !strings.ContainsAny(pattern, "*?[") {
+			forms = append(forms, resolvePath(pattern))
+		}
+
+		for _, form := range forms {
+			for _, candidate := range candidates {
+				if matchGlob(form, candidate) || (subtree && matchGlob(filepath.Join(form, "**"), candidate)) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// cleanAbsPath returns the absolute, lexically cleaned form of path.
+func cleanAbsPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+
+	return abs
+}
+
+// resolvePath returns the absolute form of path with symbolic links evaluated. Components that do
+// not exist yet are appended to the resolved form of their closest existing parent.
+func resolvePath(path string) string {
+	current := cleanAbsPath(path)
+
+	var missing []string
+	for {
+		if resolved, err := filepath.EvalSymlinks(current); err == nil {
+			return filepath.Join(append([]string{resolved}, missing...)...)
+		}
+
+		parent := filepath.Dir(current)
+		if parent == current {
+			return cleanAbsPath(path)
+		}
+		missing = append([]string{filepath.Base(current)}, missing...)
+		current = parent
+	}
+}
+
+// resolveLinkPath returns the absolute form of path with symbolic links evaluated in its parent
+// directories only, so that a link at path is not followed.
+func resolveLinkPath(path string) string {
+	clean := cleanAbsPath(path)
+
+	return filepath.Join(resolvePath(filepath.Dir(clean)), filepath.Base(clean))
+}
+
+// isSubPath reports whether path is parent or below it, once symbolic links are resolved.
+func isSubPath(parent, path string) bool {
+	rel, err := filepath.Rel(resolvePath(parent), resolvePath(path))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,108 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// ExtractRename is a rule rewriting or dropping archive entry names.
+type ExtractRename struct {
+	Pattern     types.String `tfsdk:"pattern"`
+	Replacement types.String `tfsdk:"replacement"`
+	Drop        types.Bool   `tfsdk:"drop"`
+}
+
+// renameSchema returns the schema of the rename attribute shared by the extraction resources.
+func renameSchema() schema.ListNestedAttribute {
+	return schema.ListNestedAttribute{
+		Optional:    true,
+		Description: "Ordered rules applied to each entry name before its destination path is computed. Every rule whose pattern matches is applied in turn, and rewritten paths must stay inside the destination.",
+		NestedObject: schema.NestedAttributeObject{
+			Attributes: map[string]schema.Attribute{
+				"pattern": schema.StringAttribute{
+					Required:    true,
+					Description: "Regular expression matched against the entry name, e.g. `^bin/`.",
+					Validators: []validator.String{
+						stringvalidator.LengthAtLeast(1),
+					},
+				},
+				"replacement": schema.StringAttribute{
+					Optional:    true,
+					Description: "Replacement for the matched text. Supports `$1` style references to capture groups. Defaults to an empty string.",
+					Validators: []validator.String{
+						stringvalidator.ConflictsWith(path.MatchRelative().AtParent().AtName("drop")),
+					},
+				},
+				"drop": schema.BoolAttribute{
+					Optional:    true,
+					Description: "Skip entries matching the pattern instead of renaming them.",
+				},
+			},
+		},
+	}
+}
+
+type renameRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+	drop        bool
+}
+
+// entryRenamer applies the compiled rename rules to archive entry names.
+type entryRenamer []renameRule
+
+func newEntryRenamer(rules []ExtractRename) (entryRenamer, error) {
+	renamer := make(entryRenamer, 0, len(rules))
+	for i, rule := range rules {
+		pattern, err := regexp.Compile(rule.Pattern.ValueString())
+		if err != nil {
+			return nil, fmt.Errorf("rename rule %d has an invalid pattern '%s': %w", i, rule.Pattern.ValueString(), err)
+		}
+		renamer = append(renamer, renameRule{
+			pattern:     pattern,
+			replacement: rule.Replacement.ValueString(),
+			drop:        rule.Drop.ValueBool(),
+		})
+	}
+
+	return renamer, nil
+}
+
+// apply returns the rewritten entry name. It returns false when the entry is dropped or its
+// name is rewritten to an empty string.
+func (r entryRenamer) apply(name string) (string, bool) {
+	for _, rule := range r {
+		if !rule.pattern.MatchString(name) {
+			continue
+		}
+		if rule.drop {
+			return "", false
+		}
+		name = rule.pattern.ReplaceAllString(name, rule.replacement)
+	}
+
+	return name, name != ""
+}
+
+// renameRulesEqual reports whether two lists of rename rules are the same.
+func renameRulesEqual(a, b []ExtractRename) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Pattern.Equal(b[i].Pattern) || !a[i].Replacement.Equal(b[i].Replacement) || !a[i].Drop.Equal(b[i].Drop) {
+			return false
+		}
+	}
+
+	return true
+}
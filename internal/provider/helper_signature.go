@@ -0,0 +1,228 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	// maxSignatureSize limits how much of a downloaded signature file is read.
+	maxSignatureSize = 1 << 20
+
+	// signatureDownloadTimeout bounds the download of a signature, so that a stalled server does
+	// not hang the apply.
+	signatureDownloadTimeout = 60 * time.Second
+)
+
+// archiveSignature holds the detached signature settings used to verify a downloaded archive.
+type archiveSignature struct {
+	Signature    string
+	SignatureURL string
+	PublicKey    string
+}
+
+func newArchiveSignature(signature, signatureURL, publicKey types.String) archiveSignature {
+	return archiveSignature{
+		Signature:    signature.ValueString(),
+		SignatureURL: signatureURL.ValueString(),
+		PublicKey:    publicKey.ValueString(),
+	}
+}
+
+func (s archiveSignature) isSet() bool {
+	return s.PublicKey != ""
+}
+
+// verifyArchiveSignature verifies the archive at path against a detached OpenPGP or minisign signature.
+// The signature format is detected from the public key.
+func verifyArchiveSignature(ctx context.Context, path string, signature archiveSignature) error {
+	if !signature.isSet() {
+		return nil
+	}
+
+	signatureData := []byte(signature.Signature)
+	if signature.SignatureURL != "" {
+		var err error
+		signatureData, err = downloadSignature(ctx, signature.SignatureURL)
+		if err != nil {
+			return err
+		}
+	}
+	if len(bytes.TrimSpace(signatureData)) == 0 {
+		return fmt.Errorf("no signature provided")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("unable to open file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	if strings.Contains(signature.PublicKey, "BEGIN PGP PUBLIC KEY BLOCK") {
+		return verifyOpenPGPSignature(file, signatureData, signature.PublicKey)
+	}
+
+	return verifyMinisignSignature(file, signatureData, signature.PublicKey)
+}
+
+// downloadSignature fetches a detached signature from a URL.
+func downloadSignature(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request for URL '%s': %w", url, err)
+	}
+
+	client := &http.Client{Timeout: signatureDownloadTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making HTTP request to '%s': %w", url, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download signature, HTTP status: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSignatureSize))
+	if err != nil {
+		return nil, fmt.Errorf("error reading signature from '%s': %w", url, err)
+	}
+
+	return data, nil
+}
+
+// verifyOpenPGPSignature checks an armored or binary detached OpenPGP signature.
+func verifyOpenPGPSignature(signed io.Reader, signatureData []byte, publicKey string) error {
+	keyring, err := openpgp.ReadArmoredKeyRing(strings.NewReader(publicKey))
+	if err != nil {
+		return fmt.Errorf("failed to read OpenPGP public key: %w", err)
+	}
+
+	if bytes.Contains(signatureData, []byte("BEGIN PGP SIGNATURE")) {
+		_, err = openpgp.CheckArmoredDetachedSignature(keyring, signed, bytes.NewReader(signatureData), nil)
+	} else {
+		_, err = openpgp.CheckDetachedSignature(keyring, signed, bytes.NewReader(signatureData), nil)
+	}
+	if err != nil {
+		return fmt.Errorf("OpenPGP signature verification failed: %w", err)
+	}
+
+	return nil
+}
+
+// verifyMinisignSignature checks a minisign signature, including its trusted comment. A bare
+// base64 ed25519 public key and signature are accepted as well.
+func verifyMinisignSignature(signed io.Reader, signatureData []byte, publicKey string) error {
+	keyBytes, err := decodeMinisignLine(publicKey)
+	if err != nil {
+		return fmt.Errorf("failed to decode public key: %w", err)
+	}
+
+	// Bare ed25519 key and signature
+	if len(keyBytes) == ed25519.PublicKeySize {
+		sig, err := decodeMinisignLine(string(signatureData))
+		if err != nil || len(sig) != ed25519.SignatureSize {
+			return fmt.Errorf("invalid ed25519 signature")
+		}
+		message, err := io.ReadAll(signed)
+		if err != nil {
+			return fmt.Errorf("unable to read file: %w", err)
+		}
+		if !ed25519.Verify(keyBytes, message, sig) {
+			return fmt.Errorf("ed25519 signature verification failed")
+		}
+		return nil
+	}
+
+	if len(keyBytes) != 42 || string(keyBytes[:2]) != "Ed" {
+		return fmt.Errorf("unsupported public key format")
+	}
+	keyID, key := keyBytes[2:10], ed25519.PublicKey(keyBytes[10:])
+
+	lines := minisignLines(string(signatureData))
+	if len(lines) < 4 {
+		return fmt.Errorf("invalid minisign signature: expected 4 lines, got %d", len(lines))
+	}
+
+	sigBytes, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil || len(sigBytes) != 74 {
+		return fmt.Errorf("invalid minisign signature line")
+	}
+	algorithm, sigKeyID, sig := string(sigBytes[:2]), sigBytes[2:10], sigBytes[10:]
+	if !bytes.Equal(keyID, sigKeyID) {
+		return fmt.Errorf("signature key ID %X does not match public key ID %X", sigKeyID, keyID)
+	}
+
+	var message []byte
+	switch algorithm {
+	case "Ed":
+		message, err = io.ReadAll(signed)
+		if err != nil {
+			return fmt.Errorf("unable to read file: %w", err)
+		}
+	case "ED":
+		hash, _ := blake2b.New512(nil)
+		if _, err := io.Copy(hash, signed); err != nil {
+			return fmt.Errorf("unable to calculate hash: %w", err)
+		}
+		message = hash.Sum(nil)
+	default:
+		return fmt.Errorf("unsupported minisign signature algorithm '%s'", algorithm)
+	}
+
+	if !ed25519.Verify(key, message, sig) {
+		return fmt.Errorf("minisign signature verification failed")
+	}
+
+	trustedComment, ok := strings.CutPrefix(lines[2], "trusted comment: ")
+	if !ok {
+		return fmt.Errorf("invalid minisign trusted comment")
+	}
+	globalSig, err := base64.StdEncoding.DecodeString(lines[3])
+	if err != nil || len(globalSig) != ed25519.SignatureSize {
+		return fmt.Errorf("invalid minisign global signature")
+	}
+	if !ed25519.Verify(key, append(append([]byte{}, sig...), trustedComment...), globalSig) {
+		return fmt.Errorf("minisign trusted comment verification failed")
+	}
+
+	return nil
+}
+
+// decodeMinisignLine decodes the last non-comment line of a minisign key or signature.
+func decodeMinisignLine(value string) ([]byte, error) {
+	lines := minisignLines(value)
+	for i := len(lines) - 1; i >= 0; i-- {
+		if !strings.Contains(lines[i], "comment:") {
+			return base64.StdEncoding.DecodeString(lines[i])
+		}
+	}
+
+	return nil, fmt.Errorf("no key data found")
+}
+
+func minisignLines(value string) []string {
+	var lines []string
+	for _, line := range strings.Split(value, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
@@ -0,0 +1,74 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	// paxGNUSparsePrefix prefixes the PAX records of GNU sparse entries in formats 0.0, 0.1 and 1.0.
+	paxGNUSparsePrefix = "GNU.sparse."
+
+	// sparseBlockSize is the granularity at which runs of zeros are turned into holes.
+	sparseBlockSize = 4096
+)
+
+// isSparseTarEntry reports whether a tar entry was stored as a GNU or PAX sparse file.
+func isSparseTarEntry(header *tar.Header) bool {
+	if header.Typeflag == tar.TypeGNUSparse {
+		return true
+	}
+
+	for key := range header.PAXRecords {
+		if strings.HasPrefix(key, paxGNUSparsePrefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// writeSparseFile copies reader to file, seeking over blocks of zeros instead of writing them so
+// that the filesystem leaves holes. It returns the logical size of the file and the number of
+// bytes physically written.
+func writeSparseFile(file *os.File, reader io.Reader) (int64, int64, error) {
+	var logical, physical int64
+	block := make([]byte, sparseBlockSize)
+	zeros := make([]byte, sparseBlockSize)
+
+	for {
+		n, err := io.ReadFull(reader, block)
+		if n > 0 {
+			if bytes.Equal(block[:n], zeros[:n]) {
+				if _, err := file.Seek(int64(n), io.SeekCurrent); err != nil {
+					return logical, physical, err
+				}
+			} else {
+				if _, err := file.Write(block[:n]); err != nil {
+					return logical, physical, err
+				}
+				physical += int64(n)
+			}
+			logical += int64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return logical, physical, err
+		}
+	}
+
+	// Extend the file over a trailing hole, which seeking alone does not do
+	if err := file.Truncate(logical); err != nil {
+		return logical, physical, err
+	}
+
+	return logical, physical, nil
+}
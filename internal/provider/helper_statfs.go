@@ -0,0 +1,22 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import "errors"
+
+// errStatfsUnsupported is returned where filesystem usage cannot be queried.
+var errStatfsUnsupported = errors.New("filesystem usage is not supported on this platform")
+
+// filesystemUsage describes the filesystem a path is on.
+type filesystemUsage struct {
+	Type         string
+	MountPoint   string
+	MountOptions []string
+
+	TotalBytes     uint64
+	FreeBytes      uint64
+	AvailableBytes uint64
+	TotalInodes    uint64
+	FreeInodes     uint64
+}
@@ -0,0 +1,49 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build darwin
+
+package provider
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// statFilesystem returns the usage of the filesystem path is on. The type, mount point and
+// options come from statfs itself, since macOS has no mountinfo.
+func statFilesystem(path string) (filesystemUsage, error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(path, &stat); err != nil {
+		return filesystemUsage{}, err
+	}
+
+	blockSize := uint64(stat.Bsize)
+	options := []string{"rw"}
+	if stat.Flags&unix.MNT_RDONLY != 0 {
+		options[0] = "ro"
+	}
+	for _, flag := range []struct {
+		mask uint32
+		name string
+	}{
+		{unix.MNT_NOSUID, "nosuid"},
+		{unix.MNT_NODEV, "nodev"},
+		{unix.MNT_NOEXEC, "noexec"},
+		{unix.MNT_NOATIME, "noatime"},
+	} {
+		if stat.Flags&flag.mask != 0 {
+			options = append(options, flag.name)
+		}
+	}
+
+	return filesystemUsage{
+		Type:           unix.ByteSliceToString(stat.Fstypename[:]),
+		MountPoint:     unix.ByteSliceToString(stat.Mntonname[:]),
+		MountOptions:   options,
+		TotalBytes:     stat.Blocks * blockSize,
+		FreeBytes:      stat.Bfree * blockSize,
+		AvailableBytes: stat.Bavail * blockSize,
+		TotalInodes:    stat.Files,
+		FreeInodes:     stat.Ffree,
+	}, nil
+}
@@ -0,0 +1,126 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build linux
+
+package provider
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// mountInfoPath lists the mounts visible to the provider process.
+const mountInfoPath = "/proc/self/mountinfo"
+
+// statFilesystem returns the usage of the filesystem path is on, with its type, mount point and
+// mount options taken from the mount table.
+func statFilesystem(path string) (filesystemUsage, error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(path, &stat); err != nil {
+		return filesystemUsage{}, err
+	}
+
+	// Block counts are in units of the fragment size, as reported by df
+	blockSize := uint64(stat.Frsize)
+	if blockSize == 0 {
+		blockSize = uint64(stat.Bsize)
+	}
+
+	usage := filesystemUsage{
+		TotalBytes:     stat.Blocks * blockSize,
+		FreeBytes:      stat.Bfree * blockSize,
+		AvailableBytes: stat.Bavail * blockSize,
+		TotalInodes:    stat.Files,
+		FreeInodes:     stat.Ffree,
+	}
+
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return usage, err
+	}
+	if resolved, err = filepath.Abs(resolved); err != nil {
+		return usage, err
+	}
+
+	mounts, err := os.Open(mountInfoPath)
+	if err != nil {
+		return usage, fmt.Errorf("failed to read the mount table: %w", err)
+	}
+	defer func() { _ = mounts.Close() }()
+
+	// The mount is the one with the longest mount point containing the path. A later entry with
+	// the same mount point is mounted over the earlier one. Mount points are compared lexically,
+	// since resolving them could block on unreachable network filesystems.
+	scanner := bufio.NewScanner(mounts)
+	for scanner.Scan() {
+		mount, ok := parseMountInfoLine(scanner.Text())
+		if !ok || !containsPath(mount.MountPoint, resolved) || len(mount.MountPoint) < len(usage.MountPoint) {
+			continue
+		}
+		usage.Type = mount.Type
+		usage.MountPoint = mount.MountPoint
+		usage.MountOptions = mount.MountOptions
+	}
+	if err := scanner.Err(); err != nil {
+		return usage, fmt.Errorf("failed to read the mount table: %w", err)
+	}
+
+	return usage, nil
+}
+
+// parseMountInfoLine parses a line of /proc/self/mountinfo, which holds the mount ID, parent ID,
+// device, root, mount point, mount options, optional fields terminated by "-", filesystem type,
+// source and superblock options. See proc(5).
+func parseMountInfoLine(line string) (filesystemUsage, bool) {
+	fields := strings.Fields(line)
+	separator := -1
+	for i := 6; i < len(fields); i++ {
+		if fields[i] == "-" {
+			separator = i
+			break
+		}
+	}
+	if len(fields) < 6 || separator < 0 || separator+1 >= len(fields) {
+		return filesystemUsage{}, false
+	}
+
+	return filesystemUsage{
+		Type:         unescapeMountInfo(fields[separator+1]),
+		MountPoint:   unescapeMountInfo(fields[4]),
+		MountOptions: strings.Split(fields[5], ","),
+	}, true
+}
+
+// unescapeMountInfo decodes the octal escapes, such as "\040" for a space, of a mountinfo field.
+func unescapeMountInfo(field string) string {
+	if !strings.Contains(field, `\`) {
+		return field
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(field); i++ {
+		if field[i] == '\\' && i+4 <= len(field) {
+			if value, err := strconv.ParseUint(field[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(value))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(field[i])
+	}
+
+	return b.String()
+}
+
+// containsPath lexically reports whether path is mountPoint or below it.
+func containsPath(mountPoint, path string) bool {
+	rel, err := filepath.Rel(mountPoint, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,11 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build !linux && !darwin
+
+package provider
+
+// statFilesystem is not supported on this platform.
+func statFilesystem(path string) (filesystemUsage, error) {
+	return filesystemUsage{}, errStatfsUnsupported
+}
@@ -0,0 +1,381 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"terraform-provider-utilities/internal/fsmeta"
+)
+
+const (
+	syncCompareChecksum = "checksum"
+	syncCompareMtime    = "mtime"
+
+	// syncDirectoryDigest is the manifest value of a directory, which has no content to compare.
+	syncDirectoryDigest = "dir"
+)
+
+// syncOptions controls which entries are synchronized and how they are compared.
+type syncOptions struct {
+	Compare       string
+	Include       []string
+	Exclude       []string
+	PreserveMode  bool
+	PreserveOwner bool
+	Parallelism   int
+}
+
+// excluded reports whether an entry, given by its slash-separated path relative to the root, is
+// left out. Excluded directories are not descended into, and include patterns only apply to files.
+func (o syncOptions) excluded(rel string, isDir bool) bool {
+	if matchAnyGlob(o.Exclude, rel) {
+		return true
+	}
+
+	return !isDir && len(o.Include) > 0 && !matchAnyGlob(o.Include, rel)
+}
+
+func (o syncOptions) workers() int {
+	if o.Parallelism > 0 {
+		return o.Parallelism
+	}
+
+	return runtime.NumCPU()
+}
+
+// syncEntry is a file or directory of a synchronized tree.
+type syncEntry struct {
+	Path   string
+	IsDir  bool
+	Info   os.FileInfo
+	Digest string
+}
+
+// syncManifestKey returns the manifest key of an entry: its relative path, with a trailing slash
+// for directories.
+func syncManifestKey(rel string, isDir bool) string {
+	if isDir {
+		return rel + "/"
+	}
+
+	return rel
+}
+
+// digest describes an entry for comparison: its SHA-256 or size and modification time, followed
+// by the permissions and owner when they are preserved.
+func (o syncOptions) digest(p string, info os.FileInfo) (string, error) {
+	var parts []string
+
+	switch {
+	case info.IsDir():
+		parts = append(parts, syncDirectoryDigest)
+	case o.Compare == syncCompareMtime:
+		parts = append(parts, fmt.Sprintf("%d", info.Size()), fmt.Sprintf("%d", info.ModTime().Unix()))
+	default:
+		hash, err := calculateFileHash(p)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, hash)
+	}
+
+	if o.PreserveMode {
+		parts = append(parts, fmt.Sprintf("%04o", info.Mode().Perm()))
+	}
+	if o.PreserveOwner {
+		if uid, gid, ok := fsmeta.Ownership(info); ok {
+			parts = append(parts, fmt.Sprintf("%d", uid), fmt.Sprintf("%d", gid))
+		}
+	}
+
+	return strings.Join(parts, ":"), nil
+}
+
+// scanSyncTree walks root with a pool of parallel workers and returns its entries keyed by
+// manifest key. Directories are read and files are hashed concurrently. A missing root is empty.
+func scanSyncTree(root string, options syncOptions) (map[string]syncEntry, error) {
+	entries := map[string]syncEntry{}
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		return entries, nil
+	}
+
+	type job struct {
+		path string
+		info os.FileInfo
+	}
+
+	var (
+		mu       sync.Mutex
+		cond     = sync.NewCond(&mu)
+		queue    = []job{{path: root}}
+		pending  = 1
+		firstErr error
+		wg       sync.WaitGroup
+	)
+
+	// process handles one job and returns the jobs it discovered
+	process := func(j job) ([]job, error) {
+		if j.info != nil && !j.info.IsDir() {
+			digest, err := options.digest(j.path, j.info)
+			if err != nil {
+				return nil, err
+			}
+			rel, _ := filepath.Rel(root, j.path)
+			mu.Lock()
+			entries[syncManifestKey(filepath.ToSlash(rel), false)] = syncEntry{Path: j.path, Info: j.info, Digest: digest}
+			mu.Unlock()
+			return nil, nil
+		}
+
+		if j.info != nil {
+			digest, err := options.digest(j.path, j.info)
+			if err != nil {
+				return nil, err
+			}
+			rel, _ := filepath.Rel(root, j.path)
+			mu.Lock()
+			entries[syncManifestKey(filepath.ToSlash(rel), true)] = syncEntry{Path: j.path, IsDir: true, Info: j.info, Digest: digest}
+			mu.Unlock()
+		}
+
+		dirEntries, err := os.ReadDir(j.path)
+		if err != nil {
+			return nil, err
+		}
+
+		var discovered []job
+		for _, d := range dirEntries {
+			p := filepath.Join(j.path, d.Name())
+			rel, err := filepath.Rel(root, p)
+			if err != nil {
+				return nil, err
+			}
+			info, err := os.Lstat(p)
+			if err != nil {
+				return nil, err
+			}
+			// Symbolic links and special files are not synchronized
+			if !info.IsDir() && !info.Mode().IsRegular() {
+				continue
+			}
+			if options.excluded(filepath.ToSlash(rel), info.IsDir()) {
+				continue
+			}
+			discovered = append(discovered, job{path: p, info: info})
+		}
+
+		return discovered, nil
+	}
+
+	for i := 0; i < options.workers(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				mu.Lock()
+				for len(queue) == 0 && pending > 0 {
+					cond.Wait()
+				}
+				if pending == 0 {
+					mu.Unlock()
+					return
+				}
+				j := queue[len(queue)-1]
+				queue = queue[:len(queue)-1]
+				mu.Unlock()
+
+				discovered, err := process(j)
+
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+				// Stop queueing work after the first error, the pending jobs drain quickly
+				if firstErr == nil {
+					queue = append(queue, discovered...)
+					pending += len(discovered)
+				}
+				pending--
+				cond.Broadcast()
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, fmt.Errorf("failed to scan '%s': %w", root, firstErr)
+	}
+
+	return entries, nil
+}
+
+// syncManifest returns the digests of the entries keyed by manifest key.
+func syncManifest(entries map[string]syncEntry) map[string]string {
+	manifest := make(map[string]string, len(entries))
+	for key, entry := range entries {
+		manifest[key] = entry.Digest
+	}
+
+	return manifest
+}
+
+// syncResult counts the entries changed by a synchronization.
+type syncResult struct {
+	Copied  int64
+	Deleted int64
+}
+
+// syncTree makes destination match source: directories are created, files that are missing or
+// differ are copied in parallel and, with deleteExtraneous, destination entries that are not in
+// source are removed.
+func syncTree(destination string, sourceEntries, destinationEntries map[string]syncEntry, deleteExtraneous bool, options syncOptions) (syncResult, error) {
+	var result syncResult
+
+	keys := make([]string, 0, len(sourceEntries))
+	for key := range sourceEntries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// Create directories first, parents before children
+	var copies []string
+	for _, key := range keys {
+		entry := sourceEntries[key]
+
+		// Remove a destination entry of the other type, such as a file where a directory belongs
+		if conflict, ok := destinationEntries[syncManifestKey(strings.TrimSuffix(key, "/"), !entry.IsDir)]; ok {
+			if err := os.RemoveAll(conflict.Path); err != nil {
+				return result, fmt.Errorf("failed to remove '%s': %w", conflict.Path, err)
+			}
+		}
+
+		if !entry.IsDir {
+			if existing, ok := destinationEntries[key]; !ok || existing.Digest != entry.Digest {
+				copies = append(copies, key)
+			}
+			continue
+		}
+
+		target := filepath.Join(destination, filepath.FromSlash(strings.TrimSuffix(key, "/")))
+		if err := os.MkdirAll(target, 0755); err != nil {
+			return result, fmt.Errorf("failed to create directory '%s': %w", target, err)
+		}
+		if err := applySyncAttributes(target, entry.Info, options); err != nil {
+			return result, err
+		}
+	}
+
+	// Copy the files that differ with a pool of workers
+	work := make(chan string)
+	errs := make(chan error, len(copies))
+	var wg sync.WaitGroup
+	for i := 0; i < options.workers(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := range work {
+				errs <- copySyncFile(sourceEntries[key], filepath.Join(destination, filepath.FromSlash(key)), options)
+			}
+		}()
+	}
+	for _, key := range copies {
+		work <- key
+	}
+	close(work)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			return result, err
+		}
+	}
+	result.Copied = int64(len(copies))
+
+	if !deleteExtraneous {
+		return result, nil
+	}
+
+	// Remove extraneous entries, children before parents. Directories that still hold excluded
+	// files are kept.
+	var extraneous []string
+	for key, entry := range destinationEntries {
+		_, ok := sourceEntries[key]
+		// Entries replaced by the other type were removed above
+		_, replaced := sourceEntries[syncManifestKey(strings.TrimSuffix(key, "/"), !entry.IsDir)]
+		if !ok && !replaced {
+			extraneous = append(extraneous, key)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(extraneous)))
+	for _, key := range extraneous {
+		entry := destinationEntries[key]
+		if entry.IsDir {
+			if children, err := os.ReadDir(entry.Path); err == nil && len(children) > 0 {
+				continue
+			}
+		}
+		if err := os.Remove(entry.Path); err != nil && !os.IsNotExist(err) {
+			return result, fmt.Errorf("failed to remove '%s': %w", entry.Path, err)
+		}
+		result.Deleted++
+	}
+
+	return result, nil
+}
+
+// copySyncFile atomically copies a source file to target, keeping its modification time so that
+// mtime comparison sees it as unchanged.
+func copySyncFile(entry syncEntry, target string, options syncOptions) error {
+	input, err := os.Open(entry.Path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = input.Close() }()
+
+	mode := os.FileMode(0644)
+	if options.PreserveMode {
+		mode = entry.Info.Mode().Perm()
+	}
+	uid, gid := -1, -1
+	if options.PreserveOwner {
+		if fileUID, fileGID, ok := fsmeta.Ownership(entry.Info); ok {
+			uid, gid = fileUID, fileGID
+		}
+	}
+
+	if _, _, err := writeFileAtomic(target, input, mode, uid, gid); err != nil {
+		return fmt.Errorf("failed to copy '%s' to '%s': %w", entry.Path, target, err)
+	}
+	if err := os.Chtimes(target, entry.Info.ModTime(), entry.Info.ModTime()); err != nil {
+		return fmt.Errorf("failed to set modification time of '%s': %w", target, err)
+	}
+
+	return nil
+}
+
+// applySyncAttributes applies the preserved permissions and owner of a source directory.
+func applySyncAttributes(target string, info os.FileInfo, options syncOptions) error {
+	if options.PreserveMode {
+		if err := os.Chmod(target, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("failed to set permissions of '%s': %w", target, err)
+		}
+	}
+	if options.PreserveOwner {
+		if uid, gid, ok := fsmeta.Ownership(info); ok {
+			if err := os.Lchown(target, uid, gid); err != nil {
+				return fmt.Errorf("failed to set ownership of '%s': %w", target, err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,205 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// templateSuffix marks the files that are rendered. Other files are copied verbatim.
+const templateSuffix = ".tmpl"
+
+// renderedEntry is a directory or file of a rendered template directory.
+type renderedEntry struct {
+	// Path is the destination path, RelPath the path relative to the destination directory.
+	Path    string
+	RelPath string
+	IsDir   bool
+	Mode    os.FileMode
+
+	// Source is the file copied verbatim, Content the output of a template.
+	Source  string
+	Content []byte
+	SHA256  string
+}
+
+// open returns the contents written to the destination file.
+func (e renderedEntry) open() (io.ReadCloser, error) {
+	if e.Source != "" {
+		return os.Open(e.Source)
+	}
+
+	return io.NopCloser(bytes.NewReader(e.Content)), nil
+}
+
+// renderTemplateDirectory renders every template below sourceDir with vars and lists the files
+// copied verbatim, keeping their layout relative to destinationDir. Entries are returned in
+// lexical order, so that directories come before their contents.
+func renderTemplateDirectory(sourceDir, destinationDir string, vars map[string]string, helpers bool) ([]renderedEntry, error) {
+	var entries []renderedEntry
+
+	// WalkDir does not descend into a root that is a symbolic link
+	sourceDir, err := filepath.EvalSymlinks(sourceDir)
+	if err != nil {
+		return nil, err
+	}
+
+	err = filepath.WalkDir(sourceDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == sourceDir {
+			return nil
+		}
+
+		rel, err := filepath.Rel(sourceDir, p)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		entry := renderedEntry{Mode: info.Mode().Perm()}
+		switch {
+		case d.IsDir():
+			entry.IsDir = true
+		case !info.Mode().IsRegular():
+			// Symbolic links and special files are not part of the rendered tree
+			return nil
+		case strings.HasSuffix(rel, templateSuffix):
+			rel = strings.TrimSuffix(rel, templateSuffix)
+			if entry.Content, err = renderTemplateFile(p, vars, helpers); err != nil {
+				return err
+			}
+			entry.SHA256 = fmt.Sprintf("%x", sha256.Sum256(entry.Content))
+		default:
+			entry.Source = p
+			if entry.SHA256, err = calculateFileHash(p); err != nil {
+				return err
+			}
+		}
+		entry.RelPath = filepath.ToSlash(rel)
+		entry.Path = filepath.Join(destinationDir, rel)
+
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// renderTemplateFile renders a single Go text/template file. Referencing a variable that is not
+// set is an error.
+func renderTemplateFile(path string, vars map[string]string, helpers bool) ([]byte, error) {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl := template.New(filepath.Base(path)).Option("missingkey=error")
+	if helpers {
+		tmpl = tmpl.Funcs(templateHelpers())
+	}
+	if tmpl, err = tmpl.Parse(string(text)); err != nil {
+		return nil, fmt.Errorf("failed to parse template '%s': %w", path, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return nil, fmt.Errorf("failed to render template '%s': %w", path, err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeRenderedEntries creates the directories and atomically writes the files of a rendered
+// template directory.
+func writeRenderedEntries(entries []renderedEntry) error {
+	for _, entry := range entries {
+		if entry.IsDir {
+			if err := os.MkdirAll(entry.Path, entry.Mode|0700); err != nil {
+				return fmt.Errorf("failed to create directory '%s': %w", entry.Path, err)
+			}
+			continue
+		}
+
+		reader, err := entry.open()
+		if err != nil {
+			return err
+		}
+		_, _, err = writeFileAtomic(entry.Path, reader, entry.Mode, -1, -1)
+		_ = reader.Close()
+		if err != nil {
+			return fmt.Errorf("failed to write '%s': %w", entry.Path, err)
+		}
+	}
+
+	return nil
+}
+
+// templateHelpers returns a subset of the Sprig template functions, with the same names and
+// argument order so that pipelines such as `{{ .name | default "app" | upper }}` work.
+func templateHelpers() template.FuncMap {
+	return template.FuncMap{
+		"upper":      strings.ToUpper,
+		"lower":      strings.ToLower,
+		"trim":       strings.TrimSpace,
+		"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+		"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+		"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+		"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+		"splitList":  func(sep, s string) []string { return strings.Split(s, sep) },
+		"join":       func(sep string, list []string) string { return strings.Join(list, sep) },
+		"quote":      func(s string) string { return fmt.Sprintf("%q", s) },
+		"squote":     func(s string) string { return "'" + s + "'" },
+		"indent":     func(n int, s string) string { return indentLines(n, s) },
+		"nindent":    func(n int, s string) string { return "\n" + indentLines(n, s) },
+		"b64enc":     func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+		"b64dec": func(s string) (string, error) {
+			decoded, err := base64.StdEncoding.DecodeString(s)
+			return string(decoded), err
+		},
+		"sha256sum": func(s string) string { return fmt.Sprintf("%x", sha256.Sum256([]byte(s))) },
+		"toJson": func(v interface{}) (string, error) {
+			encoded, err := json.Marshal(v)
+			return string(encoded), err
+		},
+		"default": func(def string, value ...string) string {
+			if len(value) == 0 || value[0] == "" {
+				return def
+			}
+			return value[0]
+		},
+		"required": func(message string, value ...string) (string, error) {
+			if len(value) == 0 || value[0] == "" {
+				return "", errors.New(message)
+			}
+			return value[0], nil
+		},
+	}
+}
+
+// indentLines prefixes every line of s with n spaces.
+func indentLines(n int, s string) string {
+	pad := strings.Repeat(" ", n)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
@@ -0,0 +1,283 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"terraform-provider-utilities/internal/fsmeta"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	// paxXattrPrefix prefixes the PAX records that carry extended attributes.
+	paxXattrPrefix = "SCHILY.xattr."
+
+	// paxACLAccess and paxACLDefault carry POSIX ACLs in their short text form.
+	paxACLAccess  = "SCHILY.acl.access"
+	paxACLDefault = "SCHILY.acl.default"
+
+	xattrACLAccess  = "system.posix_acl_access"
+	xattrACLDefault = "system.posix_acl_default"
+)
+
+// POSIX ACL entry tags and the version of the binary xattr encoding used by Linux.
+const (
+	aclUserObj  = 0x01
+	aclUser     = 0x02
+	aclGroupObj = 0x04
+	aclGroup    = 0x08
+	aclMask     = 0x10
+	aclOther    = 0x20
+
+	aclUndefinedID  = 0xffffffff
+	aclXattrVersion = 2
+)
+
+// extractXattrs collects the extended attributes of extracted entries. They are restored once
+// ownership has been applied, because changing the owner of a file clears its capabilities.
+type extractXattrs struct {
+	entries []xattrEntry
+}
+
+type xattrEntry struct {
+	path    string
+	records map[string]string
+}
+
+// record remembers the PAX records of an extracted entry. The collection may be nil.
+func (x *extractXattrs) record(path string, records map[string]string) {
+	if x == nil || len(records) == 0 {
+		return
+	}
+
+	x.entries = append(x.entries, xattrEntry{path: path, records: records})
+}
+
+// headerXattrs returns the extended attributes described by the PAX records of a tar header.
+// ACLs in text form are converted to their binary xattr encoding.
+func headerXattrs(records map[string]string) (map[string][]byte, error) {
+	attrs := map[string][]byte{}
+
+	for key, value := range records {
+		switch {
+		case strings.HasPrefix(key, paxXattrPrefix):
+			attrs[strings.TrimPrefix(key, paxXattrPrefix)] = []byte(value)
+		case key == paxACLAccess || key == paxACLDefault:
+			name := xattrACLAccess
+			if key == paxACLDefault {
+				name = xattrACLDefault
+			}
+			// A raw xattr record takes precedence over the text form
+			if _, ok := records[paxXattrPrefix+name]; ok {
+				continue
+			}
+			encoded, err := encodePosixACL(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid ACL in '%s': %w", key, err)
+			}
+			attrs[name] = encoded
+		}
+	}
+
+	return attrs, nil
+}
+
+// posixACLEntry is a single entry of a POSIX ACL. The ID is only meaningful for named user and
+// group entries.
+type posixACLEntry struct {
+	tag  uint16
+	perm uint16
+	id   uint32
+}
+
+// encodePosixACL converts an ACL in short text form, e.g. "user::rwx,group::r-x,other::r--",
+// to the binary encoding stored in the system.posix_acl_* extended attributes.
+func encodePosixACL(text string) ([]byte, error) {
+	var entries []posixACLEntry
+	for _, field := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == '\n' }) {
+		parts := strings.Split(strings.TrimSpace(field), ":")
+		if len(parts) < 3 {
+			return nil, fmt.Errorf("malformed entry '%s'", field)
+		}
+
+		entry := posixACLEntry{id: aclUndefinedID}
+		qualifier := parts[1]
+		switch parts[0] {
+		case "user", "u":
+			entry.tag = aclUserObj
+			if qualifier != "" {
+				entry.tag = aclUser
+			}
+		case "group", "g":
+			entry.tag = aclGroupObj
+			if qualifier != "" {
+				entry.tag = aclGroup
+			}
+		case "mask", "m":
+			entry.tag = aclMask
+		case "other", "o":
+			entry.tag = aclOther
+		default:
+			return nil, fmt.Errorf("unknown tag '%s'", parts[0])
+		}
+
+		perm, err := parseACLPermissions(parts[2])
+		if err != nil {
+			return nil, err
+		}
+		entry.perm = perm
+
+		if entry.tag == aclUser || entry.tag == aclGroup {
+			// star appends the numeric ID after the permissions
+			if len(parts) > 3 {
+				qualifier = parts[3]
+			}
+			id, err := aclQualifierID(qualifier, entry.tag == aclUser)
+			if err != nil {
+				return nil, err
+			}
+			entry.id = id
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return encodePosixACLEntries(entries), nil
+}
+
+// encodePosixACLEntries returns the binary xattr encoding of entries, which the kernel expects
+// sorted by tag and ID.
+func encodePosixACLEntries(entries []posixACLEntry) []byte {
+	sorted := append([]posixACLEntry{}, entries...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].tag != sorted[j].tag {
+			return sorted[i].tag < sorted[j].tag
+		}
+		return sorted[i].id < sorted[j].id
+	})
+
+	encoded := binary.LittleEndian.AppendUint32(nil, aclXattrVersion)
+	for _, entry := range sorted {
+		encoded = binary.LittleEndian.AppendUint16(encoded, entry.tag)
+		encoded = binary.LittleEndian.AppendUint16(encoded, entry.perm)
+		encoded = binary.LittleEndian.AppendUint32(encoded, entry.id)
+	}
+
+	return encoded
+}
+
+// decodePosixACL parses the binary encoding stored in the system.posix_acl_* extended attributes.
+func decodePosixACL(encoded []byte) ([]posixACLEntry, error) {
+	if len(encoded) < 4 || binary.LittleEndian.Uint32(encoded) != aclXattrVersion || (len(encoded)-4)%8 != 0 {
+		return nil, fmt.Errorf("unsupported ACL encoding")
+	}
+
+	var entries []posixACLEntry
+	for data := encoded[4:]; len(data) > 0; data = data[8:] {
+		entries = append(entries, posixACLEntry{
+			tag:  binary.LittleEndian.Uint16(data),
+			perm: binary.LittleEndian.Uint16(data[2:]),
+			id:   binary.LittleEndian.Uint32(data[4:]),
+		})
+	}
+
+	return entries, nil
+}
+
+// parseACLPermissions converts permissions such as "r-x" to their bit mask.
+func parseACLPermissions(text string) (uint16, error) {
+	var perm uint16
+	for _, c := range text {
+		switch c {
+		case 'r':
+			perm |= 4
+		case 'w':
+			perm |= 2
+		case 'x':
+			perm |= 1
+		case '-':
+		default:
+			return 0, fmt.Errorf("invalid permissions '%s'", text)
+		}
+	}
+
+	return perm, nil
+}
+
+// formatACLPermissions converts a permission bit mask to its "rwx" form.
+func formatACLPermissions(perm uint16) string {
+	text := []byte("---")
+	for i, c := range "rwx" {
+		if perm&(4>>i) != 0 {
+			text[i] = byte(c)
+		}
+	}
+
+	return string(text)
+}
+
+// aclQualifierID resolves the user or group named by an ACL entry to its numeric ID.
+func aclQualifierID(qualifier string, isUser bool) (uint32, error) {
+	lookup := fsmeta.LookupGID
+	if isUser {
+		lookup = fsmeta.LookupUID
+	}
+
+	id, err := lookup(qualifier)
+	if err != nil {
+		return 0, err
+	}
+	if uint64(id) > math.MaxUint32 {
+		return 0, fmt.Errorf("invalid ID %d for '%s'", id, qualifier)
+	}
+
+	return uint32(id), nil
+}
+
+// restoreExtractXattrs sets the collected extended attributes. Attributes that cannot be set,
+// for example because the filesystem does not support them, produce warnings.
+func restoreExtractXattrs(ctx context.Context, xattrs *extractXattrs, diagnostics *diag.Diagnostics) {
+	if xattrs == nil {
+		return
+	}
+
+	for _, entry := range xattrs.entries {
+		attrs, err := headerXattrs(entry.records)
+		if err != nil {
+			diagnostics.AddWarning(
+				"Extended Attributes Not Restored",
+				fmt.Sprintf("Skipping extended attributes of '%s': %v", entry.path, err),
+			)
+			continue
+		}
+
+		names := make([]string, 0, len(attrs))
+		for name := range attrs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if err := setXattr(entry.path, name, attrs[name]); err != nil {
+				diagnostics.AddWarning(
+					"Extended Attribute Not Restored",
+					fmt.Sprintf("Failed to set '%s' on '%s': %v", name, entry.path, err),
+				)
+				continue
+			}
+			tflog.SubsystemDebug(ctx, extractLogSubsystem, "Restored extended attribute", map[string]interface{}{
+				"path": entry.path,
+				"name": name,
+			})
+		}
+	}
+}
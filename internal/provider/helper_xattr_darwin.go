@@ -0,0 +1,21 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build darwin
+
+package provider
+
+import (
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// posixACLSupported reports whether POSIX ACLs can be managed through extended attributes.
+// macOS uses its own ACL model, which is not exposed as extended attributes.
+const posixACLSupported = false
+
+// xattrNotFound reports whether err means the extended attribute does not exist.
+func xattrNotFound(err error) bool {
+	return errors.Is(err, unix.ENOATTR)
+}
@@ -0,0 +1,20 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build linux
+
+package provider
+
+import (
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// posixACLSupported reports whether POSIX ACLs can be managed through extended attributes.
+const posixACLSupported = true
+
+// xattrNotFound reports whether err means the extended attribute does not exist.
+func xattrNotFound(err error) bool {
+	return errors.Is(err, unix.ENODATA)
+}
@@ -0,0 +1,38 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build !linux && !darwin
+
+package provider
+
+import "errors"
+
+// posixACLSupported reports whether POSIX ACLs can be managed through extended attributes.
+const posixACLSupported = false
+
+var errXattrUnsupported = errors.New("extended attributes are not supported on this platform")
+
+// setXattr reports that extended attributes are not supported on this platform.
+func setXattr(path, name string, value []byte) error {
+	return errXattrUnsupported
+}
+
+// getXattr reports that extended attributes are not supported on this platform.
+func getXattr(path, name string) ([]byte, error) {
+	return nil, errXattrUnsupported
+}
+
+// removeXattr reports that extended attributes are not supported on this platform.
+func removeXattr(path, name string) error {
+	return errXattrUnsupported
+}
+
+// xattrUnsupported reports whether err means the filesystem does not support extended attributes.
+func xattrUnsupported(err error) bool {
+	return errors.Is(err, errXattrUnsupported)
+}
+
+// xattrNotFound reports whether err means the extended attribute does not exist.
+func xattrNotFound(err error) bool {
+	return false
+}
@@ -0,0 +1,54 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build linux || darwin
+
+package provider
+
+import (
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// setXattr sets an extended attribute without following symbolic links.
+func setXattr(path, name string, value []byte) error {
+	return unix.Lsetxattr(path, name, value, 0)
+}
+
+// getXattr returns the value of an extended attribute without following symbolic links.
+func getXattr(path, name string) ([]byte, error) {
+	for {
+		size, err := unix.Lgetxattr(path, name, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		value := make([]byte, size)
+		n, err := unix.Lgetxattr(path, name, value)
+		// The attribute grew between the two calls
+		if errors.Is(err, unix.ERANGE) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return value[:n], nil
+	}
+}
+
+// removeXattr removes an extended attribute without following symbolic links. Removing an
+// attribute that does not exist is not an error.
+func removeXattr(path, name string) error {
+	if err := unix.Lremovexattr(path, name); err != nil && !xattrNotFound(err) {
+		return err
+	}
+
+	return nil
+}
+
+// xattrUnsupported reports whether err means the filesystem does not support extended attributes.
+func xattrUnsupported(err error) bool {
+	return errors.Is(err, unix.ENOTSUP) || errors.Is(err, unix.EOPNOTSUPP)
+}
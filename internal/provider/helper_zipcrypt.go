@@ -0,0 +1,300 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	zipFlagEncrypted      = 0x1
+	zipFlagDataDescriptor = 0x8
+
+	// zipMethodAES marks an entry encrypted with WinZip AES. The real compression method is
+	// stored in the AES extra field.
+	zipMethodAES  = 99
+	zipExtraAES   = 0x9901
+	zipAESVersion = 1 // AE-1 entries also carry a CRC-32; AE-2 entries do not
+
+	zipCryptoHeaderSize  = 12
+	zipAESVerifierSize   = 2
+	zipAESAuthCodeSize   = 10
+	zipAESKeyIterations  = 1000
+	zipAESCounterSize    = aes.BlockSize
+	zipCryptoInitialKey0 = 0x12345678
+	zipCryptoInitialKey1 = 0x23456789
+	zipCryptoInitialKey2 = 0x34567890
+)
+
+var errZipPassword = errors.New("incorrect password")
+
+// openZipEntry opens the contents of a zip entry, decrypting ZipCrypto and WinZip AES entries
+// with password. Unencrypted entries are opened as usual.
+func openZipEntry(file *zip.File, password string) (io.ReadCloser, error) {
+	if file.Flags&zipFlagEncrypted == 0 {
+		return file.Open()
+	}
+	if password == "" {
+		return nil, fmt.Errorf("entry '%s' is encrypted and no password was provided", file.Name)
+	}
+
+	raw, err := file.OpenRaw()
+	if err != nil {
+		return nil, err
+	}
+
+	var decrypted io.Reader
+	method := file.Method
+	checkCRC := true
+	if file.Method == zipMethodAES {
+		var version uint16
+		decrypted, method, version, err = newZipAESReader(raw, file, password)
+		checkCRC = version == zipAESVersion
+	} else {
+		decrypted, err = newZipCryptoReader(raw, file, password)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt entry '%s': %w", file.Name, err)
+	}
+
+	var contents io.ReadCloser
+	switch method {
+	case zip.Store:
+		contents = io.NopCloser(decrypted)
+	case zip.Deflate:
+		contents = flate.NewReader(decrypted)
+	default:
+		return nil, fmt.Errorf("entry '%s' uses unsupported compression method %d", file.Name, method)
+	}
+
+	return &zipDecryptedEntry{ReadCloser: contents, decrypted: decrypted, checkCRC: checkCRC, hash: crc32.NewIEEE(), want: file.CRC32}, nil
+}
+
+// zipDecryptedEntry reads the decompressed contents of an encrypted entry. Once they have been
+// read it consumes the rest of the encrypted data, so that the AES authentication code is
+// verified, and checks the CRC-32.
+type zipDecryptedEntry struct {
+	io.ReadCloser
+	decrypted io.Reader
+	checkCRC  bool
+	hash      hash.Hash32
+	want      uint32
+}
+
+func (z *zipDecryptedEntry) Read(p []byte) (int, error) {
+	n, err := z.ReadCloser.Read(p)
+	z.hash.Write(p[:n])
+	if err != io.EOF {
+		return n, err
+	}
+
+	if _, drainErr := io.Copy(io.Discard, z.decrypted); drainErr != nil {
+		return n, drainErr
+	}
+	if z.checkCRC && z.hash.Sum32() != z.want {
+		// A wrong password can pass the one-byte ZipCrypto header check
+		return n, fmt.Errorf("checksum mismatch, the password may be %w", errZipPassword)
+	}
+
+	return n, io.EOF
+}
+
+// newZipCryptoReader decrypts an entry encrypted with the traditional PKWARE stream cipher.
+func newZipCryptoReader(raw io.Reader, file *zip.File, password string) (io.Reader, error) {
+	keys := &zipCryptoKeys{zipCryptoInitialKey0, zipCryptoInitialKey1, zipCryptoInitialKey2}
+	for i := 0; i < len(password); i++ {
+		keys.update(password[i])
+	}
+
+	header := make([]byte, zipCryptoHeaderSize)
+	if _, err := io.ReadFull(raw, header); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	for i := range header {
+		header[i] = keys.decrypt(header[i])
+	}
+
+	// The last header byte is the high byte of the CRC, or of the modification time when the
+	// CRC is stored in a data descriptor
+	check := byte(file.CRC32 >> 24)
+	if file.Flags&zipFlagDataDescriptor != 0 {
+		check = byte(file.ModifiedTime >> 8)
+	}
+	if header[zipCryptoHeaderSize-1] != check {
+		return nil, errZipPassword
+	}
+
+	return &zipCryptoReader{r: raw, keys: keys}, nil
+}
+
+type zipCryptoKeys [3]uint32
+
+func (k *zipCryptoKeys) update(b byte) {
+	k[0] = crc32.IEEETable[byte(k[0])^b] ^ (k[0] >> 8)
+	k[1] = (k[1]+(k[0]&0xff))*134775813 + 1
+	k[2] = crc32.IEEETable[byte(k[2])^byte(k[1]>>24)] ^ (k[2] >> 8)
+}
+
+func (k *zipCryptoKeys) decrypt(b byte) byte {
+	temp := k[2] | 2
+	plain := b ^ byte((temp*(temp^1))>>8)
+	k.update(plain)
+
+	return plain
+}
+
+type zipCryptoReader struct {
+	r    io.Reader
+	keys *zipCryptoKeys
+}
+
+func (z *zipCryptoReader) Read(p []byte) (int, error) {
+	n, err := z.r.Read(p)
+	for i := 0; i < n; i++ {
+		p[i] = z.keys.decrypt(p[i])
+	}
+
+	return n, err
+}
+
+// newZipAESReader decrypts a WinZip AES entry. It returns the real compression method of the
+// entry and the AE version, which decides whether the CRC-32 is meaningful.
+func newZipAESReader(raw io.Reader, file *zip.File, password string) (io.Reader, uint16, uint16, error) {
+	version, strength, method, err := parseZipAESExtra(file.Extra)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	var keySize int
+	switch strength {
+	case 1:
+		keySize = 16
+	case 2:
+		keySize = 24
+	case 3:
+		keySize = 32
+	default:
+		return nil, 0, 0, fmt.Errorf("unsupported AES strength %d", strength)
+	}
+	saltSize := keySize / 2
+
+	overhead := uint64(saltSize + zipAESVerifierSize + zipAESAuthCodeSize)
+	if file.CompressedSize64 < overhead {
+		return nil, 0, 0, io.ErrUnexpectedEOF
+	}
+
+	salt := make([]byte, saltSize)
+	verifier := make([]byte, zipAESVerifierSize)
+	if _, err := io.ReadFull(raw, salt); err != nil {
+		return nil, 0, 0, unexpectedEOF(err)
+	}
+	if _, err := io.ReadFull(raw, verifier); err != nil {
+		return nil, 0, 0, unexpectedEOF(err)
+	}
+
+	derived := pbkdf2.Key([]byte(password), salt, zipAESKeyIterations, 2*keySize+zipAESVerifierSize, sha1.New)
+	if !hmac.Equal(derived[2*keySize:], verifier) {
+		return nil, 0, 0, errZipPassword
+	}
+
+	block, err := aes.NewCipher(derived[:keySize])
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	mac := hmac.New(sha1.New, derived[keySize:2*keySize])
+	return &zipAESReader{
+		r:      io.TeeReader(io.LimitReader(raw, int64(file.CompressedSize64-overhead)), mac),
+		raw:    raw,
+		block:  block,
+		mac:    mac,
+		offset: zipAESCounterSize,
+	}, method, version, nil
+}
+
+// parseZipAESExtra reads the AE version, key strength and compression method from the AES
+// extra field of an entry.
+func parseZipAESExtra(extra []byte) (uint16, byte, uint16, error) {
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra)
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+		extra = extra[4:]
+		if size > len(extra) {
+			break
+		}
+		if id == zipExtraAES && size >= 7 {
+			field := extra[:size]
+			if !bytes.Equal(field[2:4], []byte("AE")) {
+				return 0, 0, 0, fmt.Errorf("unknown AES vendor '%s'", field[2:4])
+			}
+			return binary.LittleEndian.Uint16(field), field[4], binary.LittleEndian.Uint16(field[5:]), nil
+		}
+		extra = extra[size:]
+	}
+
+	return 0, 0, 0, errors.New("missing AES extra field")
+}
+
+// zipAESReader decrypts AES-CTR data with the little-endian counter used by WinZip and
+// verifies the authentication code at the end of the entry.
+type zipAESReader struct {
+	r       io.Reader
+	raw     io.Reader
+	block   cipher.Block
+	mac     hash.Hash
+	counter [zipAESCounterSize]byte
+	stream  [zipAESCounterSize]byte
+	offset  int
+	checked bool
+}
+
+func (z *zipAESReader) Read(p []byte) (int, error) {
+	n, err := z.r.Read(p)
+	for i := 0; i < n; i++ {
+		if z.offset == zipAESCounterSize {
+			z.nextKeyStream()
+		}
+		p[i] ^= z.stream[z.offset]
+		z.offset++
+	}
+
+	if err == io.EOF && !z.checked {
+		z.checked = true
+		code := make([]byte, zipAESAuthCodeSize)
+		if _, readErr := io.ReadFull(z.raw, code); readErr != nil {
+			return n, unexpectedEOF(readErr)
+		}
+		if !hmac.Equal(z.mac.Sum(nil)[:zipAESAuthCodeSize], code) {
+			return n, errors.New("authentication code mismatch")
+		}
+	}
+
+	return n, err
+}
+
+func (z *zipAESReader) nextKeyStream() {
+	for i := range z.counter {
+		z.counter[i]++
+		if z.counter[i] != 0 {
+			break
+		}
+	}
+
+	z.block.Encrypt(z.stream[:], z.counter[:])
+	z.offset = 0
+}
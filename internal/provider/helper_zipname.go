@@ -0,0 +1,97 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"archive/zip"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+)
+
+const (
+	filenameEncodingAuto     = "auto"
+	filenameEncodingUTF8     = "utf-8"
+	filenameEncodingCP437    = "cp437"
+	filenameEncodingShiftJIS = "shift_jis"
+	filenameEncodingGBK      = "gbk"
+	filenameEncodingBig5     = "big5"
+	filenameEncodingEUCKR    = "euc_kr"
+
+	// zipExtraUnicodePath is the Info-ZIP Unicode Path extra field, which carries a UTF-8 copy
+	// of a legacy encoded name.
+	zipExtraUnicodePath = 0x7075
+)
+
+var filenameEncodings = map[string]encoding.Encoding{
+	filenameEncodingCP437:    charmap.CodePage437,
+	filenameEncodingShiftJIS: japanese.ShiftJIS,
+	filenameEncodingGBK:      simplifiedchinese.GBK,
+	filenameEncodingBig5:     traditionalchinese.Big5,
+	filenameEncodingEUCKR:    korean.EUCKR,
+}
+
+// zipEntryName returns the name of a zip entry as UTF-8. Names flagged as UTF-8 are returned
+// unchanged. Other names are transcoded from filenameEncoding; in auto mode the Info-ZIP Unicode
+// Path field is preferred, valid UTF-8 is kept and anything else is read as CP437, the encoding
+// the zip specification assumes.
+func zipEntryName(file *zip.File, filenameEncoding string) (string, error) {
+	if !file.NonUTF8 || filenameEncoding == filenameEncodingUTF8 {
+		return file.Name, nil
+	}
+
+	if filenameEncoding == "" || filenameEncoding == filenameEncodingAuto {
+		if name, ok := zipUnicodePath(file); ok {
+			return name, nil
+		}
+		if utf8.ValidString(file.Name) {
+			return file.Name, nil
+		}
+		filenameEncoding = filenameEncodingCP437
+	}
+
+	enc, ok := filenameEncodings[filenameEncoding]
+	if !ok {
+		return "", fmt.Errorf("unsupported filename encoding '%s'", filenameEncoding)
+	}
+
+	name, err := enc.NewDecoder().String(file.Name)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode entry name '%s' as %s: %w", file.Name, filenameEncoding, err)
+	}
+
+	return name, nil
+}
+
+// zipUnicodePath returns the UTF-8 name stored in the Info-ZIP Unicode Path extra field, if the
+// field is present and still matches the legacy name.
+func zipUnicodePath(file *zip.File) (string, bool) {
+	extra := file.Extra
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra)
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+		extra = extra[4:]
+		if size > len(extra) {
+			break
+		}
+		// Version 1, followed by the CRC-32 of the legacy name and the UTF-8 name
+		if id == zipExtraUnicodePath && size > 5 && extra[0] == 1 {
+			field := extra[:size]
+			if binary.LittleEndian.Uint32(field[1:]) == crc32.ChecksumIEEE([]byte(file.Name)) && utf8.Valid(field[5:]) {
+				return string(field[5:]), true
+			}
+		}
+		extra = extra[size:]
+	}
+
+	return "", false
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
 var (
@@ -21,6 +22,11 @@ type utilitiesProvider struct {
 	version string
 }
 
+type utilitiesProviderModel struct {
+	ProtectedPaths types.List `tfsdk:"protected_paths"`
+	AllowedRoots   types.List `tfsdk:"allowed_roots"`
+}
+
 // New is a helper function to initialize the provider.
 func New(version string) func() provider.Provider {
 	return func() provider.Provider {
@@ -36,27 +42,60 @@ func (p *utilitiesProvider) Metadata(ctx context.Context, req provider.MetadataR
 	resp.Version = p.version
 }
 
-// Schema returns the provider schema, which is empty since no configuration is required.
+// Schema returns the provider schema. Every setting is optional.
 func (p *utilitiesProvider) Schema(ctx context.Context, req provider.SchemaRequest, resp *provider.SchemaResponse) {
 	resp.Schema = schema.Schema{
 		MarkdownDescription: `
 The Utilities provider offers various utility functions and tools for use in Terraform configurations. This provider does not require configuration.
 `,
+		Attributes: map[string]schema.Attribute{
+			"protected_paths": schema.ListAttribute{
+				ElementType:         types.StringType,
+				Optional:            true,
+				MarkdownDescription: "Additional paths that resources must never modify or delete, on top of the built-in system paths such as `/etc` and `/usr`. Paths are cleaned and symbolic links are evaluated before matching. Glob patterns are supported, and a `**` segment matches any number of directories, so `/data/db/**` protects `/data/db` and everything below it.",
+			},
+			"allowed_roots": schema.ListAttribute{
+				ElementType:         types.StringType,
+				Optional:            true,
+				MarkdownDescription: "When set, directories and extraction destinations must be inside one of these roots. Glob patterns are supported.",
+			},
+		},
 	}
 }
 
-// Configure initializes the provider with no special configuration.
+// Configure builds the path policy shared by the resources and data sources.
 func (p *utilitiesProvider) Configure(ctx context.Context, req provider.ConfigureRequest, resp *provider.ConfigureResponse) {
-	// No configuration needed
+	var data utilitiesProviderModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var protected, allowedRoots []string
+	resp.Diagnostics.Append(data.ProtectedPaths.ElementsAs(ctx, &protected, false)...)
+	resp.Diagnostics.Append(data.AllowedRoots.ElementsAs(ctx, &allowedRoots, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	policy := newPathPolicy(protected, allowedRoots)
+	resp.ResourceData = policy
+	resp.DataSourceData = policy
 }
 
 // Resources returns an empty list since no resources are implemented.
 func (p *utilitiesProvider) Resources(ctx context.Context) []func() resource.Resource {
 	return []func() resource.Resource{
+		NewResourceUtilitiesDecompressFile,
+		NewResourceUtilitiesDirectorySync,
+		NewResourceUtilitiesExtractPackage,
 		// NewResourceUtilitiesExtractTar,
 		NewResourceUtilitiesExtractTarGz,
 		NewResourceUtilitiesExtractZip,
 		NewResourceUtilitiesLocalDirectory,
+		NewResourceUtilitiesLocalFile,
+		NewResourceUtilitiesLocalSymlink,
+		NewResourceUtilitiesTemplateDirectory,
 	}
 }
 
@@ -64,6 +103,7 @@ func (p *utilitiesProvider) Resources(ctx context.Context) []func() resource.Res
 func (p *utilitiesProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
 	return []func() datasource.DataSource{
 		NewDataSourceBcryptHash,
+		NewDataSourceFilesystemUsage,
 		NewDataSourceLocalDirectory,
 	}
 }
@@ -0,0 +1,415 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+var (
+	_ resource.Resource               = (*resourceUtilitiesDecompressFile)(nil)
+	_ resource.ResourceWithConfigure  = (*resourceUtilitiesDecompressFile)(nil)
+	_ resource.ResourceWithModifyPlan = (*resourceUtilitiesDecompressFile)(nil)
+)
+
+var compressedFile = archiveKind{Name: "Compressed File", Noun: "compressed file"}
+
+type resourceUtilitiesDecompressFile struct {
+	policy *pathPolicy
+}
+
+type DecompressFile struct {
+	Source          types.String `tfsdk:"source"`
+	Url             types.String `tfsdk:"url"`
+	DestinationFile types.String `tfsdk:"destination_file"`
+	Compression     types.String `tfsdk:"compression"`
+	Owner           types.String `tfsdk:"owner"`
+	Group           types.String `tfsdk:"group"`
+	FileMode        types.String `tfsdk:"file_mode"`
+	FileHash        types.String `tfsdk:"file_hash"`
+	OutputSHA256    types.String `tfsdk:"output_sha256"`
+	OutputSize      types.Int64  `tfsdk:"output_size"`
+}
+
+// NewResourceUtilitiesDecompressFile creates a new instance of the resource.
+func NewResourceUtilitiesDecompressFile() resource.Resource {
+	return &resourceUtilitiesDecompressFile{}
+}
+
+func (r *resourceUtilitiesDecompressFile) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.policy = providerPathPolicy(req.ProviderData)
+}
+
+// Metadata sets the resource type name.
+func (r *resourceUtilitiesDecompressFile) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = "utilities_decompress_file"
+}
+
+func (r *resourceUtilitiesDecompressFile) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Decompresses a single gzip, xz, bzip2 or zstd compressed file.",
+		Attributes: map[string]schema.Attribute{
+			"source": schema.StringAttribute{
+				Optional:    true,
+				Description: "The path to the compressed source file.",
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(path.MatchRelative().AtParent().AtName("url")),
+					stringvalidator.AtLeastOneOf(path.MatchRelative().AtParent().AtName("url")),
+				},
+			},
+			"url": schema.StringAttribute{
+				Optional:    true,
+				Description: "The URL of the compressed source file.",
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(path.MatchRelative().AtParent().AtName("source")),
+					stringvalidator.RegexMatches(regexp.MustCompile(`^https?://.+`), "must be a valid HTTP(S) URL"),
+				},
+			},
+			"destination_file": schema.StringAttribute{
+				Required:    true,
+				Description: "The path of the decompressed file.",
+			},
+			"compression": schema.StringAttribute{
+				Optional:    true,
+				Description: "The compression of the source file: `gzip`, `xz`, `bzip2`, `zstd` or `auto` (default) to detect it from the file contents.",
+				Validators: []validator.String{
+					stringvalidator.OneOf("auto", compressionGzip, compressionXz, compressionBzip2, compressionZstd),
+				},
+			},
+			"owner": schema.StringAttribute{
+				Optional:    true,
+				Description: "User to own the decompressed file.",
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"group": schema.StringAttribute{
+				Optional:    true,
+				Description: "Group to own the decompressed file.",
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"file_mode": schema.StringAttribute{
+				Optional:    true,
+				Description: "Permissions to set on the decompressed file, in octal format (e.g., 0644).",
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(regexp.MustCompile(`^0[0-7]{3}$`), "must be a valid octal permission (e.g., 0644)"),
+				},
+			},
+			"file_hash": schema.StringAttribute{
+				Computed:    true,
+				Description: "The SHA-256 hash of the compressed source file, used to detect changes to the source.",
+			},
+			"output_sha256": schema.StringAttribute{
+				Computed:    true,
+				Description: "The SHA-256 hash of the decompressed file, used to detect changes to the destination.",
+			},
+			"output_size": schema.Int64Attribute{
+				Computed:    true,
+				Description: "The size of the decompressed file in bytes.",
+			},
+		},
+	}
+}
+
+// ModifyPlan plans a new decompression when the source changed or the destination drifted.
+func (r *resourceUtilitiesDecompressFile) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to plan when the resource is being created or destroyed
+	if req.Plan.Raw.IsNull() || req.State.Raw.IsNull() {
+		return
+	}
+
+	var plan, state DecompressFile
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The hash of a local source is known at plan time, a downloaded source keeps its hash until the URL changes
+	plan.FileHash = types.StringUnknown()
+	if !plan.Source.IsNull() && !plan.Source.IsUnknown() {
+		if hash, err := calculateFileHash(plan.Source.ValueString()); err == nil {
+			plan.FileHash = types.StringValue(hash)
+		}
+	} else if plan.Url.Equal(state.Url) {
+		plan.FileHash = state.FileHash
+	}
+
+	unchanged := plan.FileHash.Equal(state.FileHash) &&
+		plan.DestinationFile.Equal(state.DestinationFile) &&
+		plan.Compression.Equal(state.Compression) &&
+		state.OutputSHA256.ValueString() != ""
+
+	if unchanged {
+		plan.OutputSHA256 = state.OutputSHA256
+		plan.OutputSize = state.OutputSize
+	} else {
+		plan.OutputSHA256 = types.StringUnknown()
+		plan.OutputSize = types.Int64Unknown()
+
+		// The source is downloaded again and may have changed
+		if !plan.Url.IsNull() {
+			plan.FileHash = types.StringUnknown()
+		}
+	}
+
+	resp.Diagnostics.Append(resp.Plan.Set(ctx, &plan)...)
+}
+
+func (r *resourceUtilitiesDecompressFile) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data DecompressFile
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r.decompress(ctx, &data, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *resourceUtilitiesDecompressFile) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data DecompressFile
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	destination := data.DestinationFile.ValueString()
+
+	// Detect a modified or missing destination file
+	hash, err := calculateFileHash(destination)
+	if err != nil || hash != data.OutputSHA256.ValueString() {
+		data.OutputSHA256 = types.StringValue("")
+		resp.Diagnostics.AddWarning(
+			"Destination Drift Detected",
+			fmt.Sprintf("The file '%s' no longer matches the decompressed source, marking the resource for update.", destination),
+		)
+	}
+
+	// Detect a changed source file, the new hash is picked up during plan
+	if !data.Source.IsNull() {
+		if hash, err := calculateFileHash(data.Source.ValueString()); err == nil && hash != data.FileHash.ValueString() {
+			resp.Diagnostics.AddWarning(
+				"File Hash Mismatch Detected",
+				"The hash of the compressed source file has changed, marking the resource for update.",
+			)
+		}
+	}
+
+	// Detect drift in the ownership and permissions of the destination file
+	if err == nil {
+		ownership := newExtractOwnership(data.Owner, data.Group, data.FileMode, types.StringNull())
+		drift := extractOwnershipDrift([]string{destination}, ownership)
+		if drift.Owner != "" {
+			data.Owner = types.StringValue(drift.Owner)
+		}
+		if drift.Group != "" {
+			data.Group = types.StringValue(drift.Group)
+		}
+		if drift.FileMode != "" {
+			data.FileMode = types.StringValue(drift.FileMode)
+		}
+		if drift.isSet() {
+			resp.Diagnostics.AddWarning(
+				"Ownership Drift Detected",
+				"The ownership or permissions of the decompressed file have changed, marking the resource for update.",
+			)
+		}
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *resourceUtilitiesDecompressFile) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var planData DecompressFile
+	var stateData DecompressFile
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &planData)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &stateData)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The planned output is only known when the source and destination are unchanged
+	if planData.OutputSHA256.IsUnknown() {
+		r.decompress(ctx, &planData, &resp.Diagnostics)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		resp.Diagnostics.Append(resp.State.Set(ctx, &planData)...)
+		return
+	}
+
+	// No change, re-apply ownership without decompressing again
+	destination := planData.DestinationFile.ValueString()
+	ownership := newExtractOwnership(planData.Owner, planData.Group, planData.FileMode, types.StringNull())
+	if err := applyExtractOwnership(ctx, []string{destination}, ownership); err != nil {
+		resp.Diagnostics.AddError(
+			"Ownership Change Failed",
+			fmt.Sprintf("Error applying ownership to '%s': %v", destination, err),
+		)
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &planData)...)
+}
+
+func (r *resourceUtilitiesDecompressFile) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data DecompressFile
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	destination := data.DestinationFile.ValueString()
+	if err := os.Remove(destination); err != nil && !os.IsNotExist(err) {
+		resp.Diagnostics.AddWarning(
+			"File Deletion Failed",
+			fmt.Sprintf("Could not delete file '%s': %v", destination, err),
+		)
+	}
+
+	resp.State.RemoveResource(ctx)
+}
+
+// decompress writes the decompressed source to the destination file and records the hashes and
+// size in data.
+func (r *resourceUtilitiesDecompressFile) decompress(ctx context.Context, data *DecompressFile, diagnostics *diag.Diagnostics) {
+	source := data.Source.ValueString()
+	destination := data.DestinationFile.ValueString()
+
+	// Refuse destinations that the provider's path policy does not allow
+	if err := r.policy.checkAllowed(destination); err != nil {
+		diagnostics.AddError(
+			"Destination Not Allowed",
+			fmt.Sprintf("Refusing to decompress to '%s': %v", destination, err),
+		)
+		return
+	}
+
+	if !data.Url.IsNull() {
+		tmpFile, err := downloadArchive(ctx, compressedFile, data.Url.ValueString(), "downloaded-compressed-*", archiveSignature{}, diagnostics)
+		if err != nil {
+			return
+		}
+		defer func() { _ = os.Remove(tmpFile) }() // Ensure the temporary file is removed after decompression
+		source = tmpFile
+	}
+
+	fileHash, err := calculateFileHash(source)
+	if err != nil {
+		diagnostics.AddError(
+			"File Hash Calculation Failed",
+			fmt.Sprintf("Error calculating hash for file '%s': %v", source, err),
+		)
+		return
+	}
+
+	compression := data.Compression.ValueString()
+	if compression == "" || compression == "auto" {
+		if compression, err = detectCompression(source); err != nil {
+			diagnostics.AddError(
+				"Unknown Compression",
+				fmt.Sprintf("Set the compression attribute explicitly: %v", err),
+			)
+			return
+		}
+	}
+
+	outputHash, size, err := decompressFile(source, destination, compression)
+	if err != nil {
+		diagnostics.AddError(
+			"Decompression Failed",
+			fmt.Sprintf("Error decompressing '%s' to '%s': %v", source, destination, err),
+		)
+		return
+	}
+
+	tflog.Debug(ctx, "Decompressed file", map[string]interface{}{
+		"source":      source,
+		"destination": destination,
+		"compression": compression,
+		"size":        size,
+	})
+
+	ownership := newExtractOwnership(data.Owner, data.Group, data.FileMode, types.StringNull())
+	if err := applyExtractOwnership(ctx, []string{destination}, ownership); err != nil {
+		diagnostics.AddError(
+			"Ownership Change Failed",
+			fmt.Sprintf("Error applying ownership to '%s': %v", destination, err),
+		)
+		return
+	}
+
+	data.FileHash = types.StringValue(fileHash)
+	data.OutputSHA256 = types.StringValue(outputHash)
+	data.OutputSize = types.Int64Value(size)
+}
+
+// decompressFile decompresses source into a temporary file next to destination and renames it
+// into place, so that the destination is never left partially written. It returns the SHA-256 and
+// size of the decompressed data.
+func decompressFile(source, destination, compression string) (string, int64, error) {
+	input, err := os.Open(source)
+	if err != nil {
+		return "", 0, err
+	}
+	defer func() { _ = input.Close() }()
+
+	reader, err := newDecompressReader(input, compression)
+	if err != nil {
+		return "", 0, err
+	}
+	defer func() { _ = reader.Close() }()
+
+	if err := os.MkdirAll(filepath.Dir(destination), 0755); err != nil {
+		return "", 0, fmt.Errorf("failed to create directory '%s': %w", filepath.Dir(destination), err)
+	}
+
+	output, err := os.CreateTemp(filepath.Dir(destination), "."+filepath.Base(destination)+".*")
+	if err != nil {
+		return "", 0, err
+	}
+	defer func() { _ = os.Remove(output.Name()) }()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(output, hash), reader)
+	if err != nil {
+		_ = output.Close()
+		return "", 0, err
+	}
+	if err := output.Close(); err != nil {
+		return "", 0, err
+	}
+
+	if err := os.Chmod(output.Name(), 0644); err != nil {
+		return "", 0, err
+	}
+	if err := os.Rename(output.Name(), destination); err != nil {
+		return "", 0, err
+	}
+
+	return fmt.Sprintf("%x", hash.Sum(nil)), size, nil
+}
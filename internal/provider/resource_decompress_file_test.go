@@ -0,0 +1,82 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+)
+
+func TestResourceUtilitiesDecompressFile(t *testing.T) {
+	tempDir := t.TempDir()
+	sourcePath := filepath.Join(tempDir, "dump.sql.gz")
+	destinationPath := filepath.Join(tempDir, "out", "dump.sql")
+	content := "CREATE TABLE example (id INTEGER);\n"
+
+	file, err := os.Create(sourcePath)
+	requireNoError(t, err)
+	gzipWriter := gzip.NewWriter(file)
+	_, err = gzipWriter.Write([]byte(content))
+	requireNoError(t, err)
+	requireNoError(t, gzipWriter.Close())
+	requireNoError(t, file.Close())
+
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: TestAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+					resource "utilities_decompress_file" "example" {
+						source           = "%s"
+						destination_file = "%s"
+					}
+					`, sourcePath, destinationPath),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("utilities_decompress_file.example", "output_sha256", fmt.Sprintf("%x", sha256.Sum256([]byte(content)))),
+					resource.TestCheckResourceAttr("utilities_decompress_file.example", "output_size", fmt.Sprintf("%d", len(content))),
+					func(s *terraform.State) error {
+						data, err := os.ReadFile(destinationPath)
+						if err != nil {
+							return fmt.Errorf("failed to read decompressed file: %v", err)
+						}
+						if string(data) != content {
+							return fmt.Errorf("expected content '%s', got '%s'", content, string(data))
+						}
+						return nil
+					},
+				),
+			},
+			{
+				Config: fmt.Sprintf(`
+					resource "utilities_decompress_file" "example" {
+						source           = "%s"
+						destination_file = "%s"
+						file_mode        = "0600"
+					}
+					`, sourcePath, destinationPath),
+				Check: func(s *terraform.State) error {
+					info, err := os.Stat(destinationPath)
+					if err != nil {
+						return fmt.Errorf("failed to stat decompressed file: %v", err)
+					}
+					if info.Mode().Perm() != 0600 {
+						return fmt.Errorf("expected mode 0600, got %04o", info.Mode().Perm())
+					}
+					return nil
+				},
+			},
+		},
+	})
+}
@@ -0,0 +1,426 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+var (
+	_ resource.Resource               = (*resourceUtilitiesDirectorySync)(nil)
+	_ resource.ResourceWithConfigure  = (*resourceUtilitiesDirectorySync)(nil)
+	_ resource.ResourceWithModifyPlan = (*resourceUtilitiesDirectorySync)(nil)
+)
+
+type resourceUtilitiesDirectorySync struct {
+	policy *pathPolicy
+}
+
+type DirectorySync struct {
+	SourceDir        types.String `tfsdk:"source_dir"`
+	DestinationDir   types.String `tfsdk:"destination_dir"`
+	Compare          types.String `tfsdk:"compare"`
+	DeleteExtraneous types.Bool   `tfsdk:"delete_extraneous"`
+	Include          types.List   `tfsdk:"include"`
+	Exclude          types.List   `tfsdk:"exclude"`
+	PreserveMode     types.Bool   `tfsdk:"preserve_mode"`
+	PreserveOwner    types.Bool   `tfsdk:"preserve_owner"`
+	Parallelism      types.Int64  `tfsdk:"parallelism"`
+	Manifest         types.Map    `tfsdk:"manifest"`
+	FileCount        types.Int64  `tfsdk:"file_count"`
+}
+
+// options returns the synchronization options of the configuration.
+func (d DirectorySync) options(ctx context.Context, diagnostics *diag.Diagnostics) syncOptions {
+	options := syncOptions{
+		Compare:       syncCompareChecksum,
+		PreserveMode:  d.PreserveMode.ValueBool(),
+		PreserveOwner: d.PreserveOwner.ValueBool(),
+		Parallelism:   int(d.Parallelism.ValueInt64()),
+	}
+	if !d.Compare.IsNull() {
+		options.Compare = d.Compare.ValueString()
+	}
+	if !d.Include.IsNull() {
+		diagnostics.Append(d.Include.ElementsAs(ctx, &options.Include, false)...)
+	}
+	if !d.Exclude.IsNull() {
+		diagnostics.Append(d.Exclude.ElementsAs(ctx, &options.Exclude, false)...)
+	}
+
+	return options
+}
+
+// known reports whether every attribute that affects the manifest is known.
+func (d DirectorySync) known() bool {
+	return !d.SourceDir.IsUnknown() && !d.DestinationDir.IsUnknown() && !d.Compare.IsUnknown() &&
+		!d.Include.IsUnknown() && !d.Exclude.IsUnknown() && !d.PreserveMode.IsUnknown() &&
+		!d.PreserveOwner.IsUnknown() && !d.Parallelism.IsUnknown()
+}
+
+// setManifest records the manifest and the number of files it holds.
+func (d *DirectorySync) setManifest(ctx context.Context, manifest map[string]string, diagnostics *diag.Diagnostics) {
+	var fileCount int64
+	for key := range manifest {
+		if !strings.HasSuffix(key, "/") {
+			fileCount++
+		}
+	}
+
+	value, diags := types.MapValueFrom(ctx, types.StringType, manifest)
+	diagnostics.Append(diags...)
+	d.Manifest = value
+	d.FileCount = types.Int64Value(fileCount)
+}
+
+// NewResourceUtilitiesDirectorySync creates a new instance of the resource.
+func NewResourceUtilitiesDirectorySync() resource.Resource {
+	return &resourceUtilitiesDirectorySync{}
+}
+
+func (r *resourceUtilitiesDirectorySync) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.policy = providerPathPolicy(req.ProviderData)
+}
+
+// Metadata sets the resource type name.
+func (r *resourceUtilitiesDirectorySync) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = "utilities_directory_sync"
+}
+
+func (r *resourceUtilitiesDirectorySync) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: `
+The **utilities_directory_sync** resource mirrors a source directory into a destination directory, similar to ` + "`rsync`" + `.
+
+- **Comparison**: Files are compared by SHA-256 checksum or by size and modification time, and only the files that differ are copied. Copies are written atomically and keep the modification time of the source.
+- **Filtering**: **include** and **exclude** glob patterns select the synchronized paths, relative to the source directory. A ` + "`**`" + ` segment matches any number of directories.
+- **Drift Detection**: The computed **manifest** describes every synchronized entry. Changes in the source are detected during plan, and files modified, removed or added in the destination outside of Terraform are detected during refresh.
+- **Large Trees**: Both trees are walked, hashed and copied by a pool of parallel workers.
+- **Protected Paths**: The destination must be allowed by the provider configuration, and synchronized files are not deleted from protected paths.
+`,
+		Attributes: map[string]schema.Attribute{
+			"source_dir": schema.StringAttribute{
+				Required:    true,
+				Description: "The directory to copy from.",
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"destination_dir": schema.StringAttribute{
+				Required:    true,
+				Description: "The directory to copy to. Missing directories are created.",
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"compare": schema.StringAttribute{
+				Optional:    true,
+				Description: "How files are compared: `checksum` compares the SHA-256 of their contents, `mtime` compares their size and modification time, which is faster but misses changes that keep both. Default is `checksum`.",
+				Validators: []validator.String{
+					stringvalidator.OneOf(syncCompareChecksum, syncCompareMtime),
+				},
+			},
+			"delete_extraneous": schema.BoolAttribute{
+				Optional:    true,
+				Description: "Whether to delete files and directories of the destination that are not in the source. Excluded paths are never deleted. Default is false.",
+			},
+			"include": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Glob patterns of the files to synchronize, such as `**/*.conf`. When set, other files are skipped. Directories are always traversed.",
+			},
+			"exclude": schema.ListAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Glob patterns of the files and directories to skip, such as `.git` or `**/*.tmp`. Excluded directories are not traversed.",
+			},
+			"preserve_mode": schema.BoolAttribute{
+				Optional:    true,
+				Description: "Whether to copy the permissions of the source files and directories, and compare them. Otherwise files are written with `0644` and directories with `0755`. Default is false.",
+			},
+			"preserve_owner": schema.BoolAttribute{
+				Optional:    true,
+				Description: "Whether to copy the owner and group of the source files and directories, and compare them. Usually requires root privileges. Default is false.",
+			},
+			"parallelism": schema.Int64Attribute{
+				Optional:    true,
+				Description: "The number of parallel workers walking, hashing and copying files. Default is the number of CPUs.",
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"manifest": schema.MapAttribute{
+				ElementType: types.StringType,
+				Computed:    true,
+				Description: "The synchronized entries, keyed by their path relative to the directories, with a trailing `/` for directories. Values are the SHA-256 of the file, or its size and modification time, followed by its permissions and owner when they are preserved.",
+			},
+			"file_count": schema.Int64Attribute{
+				Computed:    true,
+				Description: "The number of synchronized files.",
+			},
+		},
+	}
+}
+
+// ModifyPlan scans the source directory during plan, so that changed source files produce a diff.
+func (r *resourceUtilitiesDirectorySync) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to do on destroy
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var data DirectorySync
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.Manifest = types.MapUnknown(types.StringType)
+	data.FileCount = types.Int64Unknown()
+
+	// A source that does not exist yet may be created earlier in the same apply
+	if _, err := os.Stat(data.SourceDir.ValueString()); data.known() && err == nil {
+		options := data.options(ctx, &resp.Diagnostics)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		entries, err := scanSyncTree(data.SourceDir.ValueString(), options)
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Error Scanning Source Directory",
+				fmt.Sprintf("Failed to scan the source directory '%s': %v", data.SourceDir.ValueString(), err),
+			)
+			return
+		}
+		data.setManifest(ctx, syncManifest(entries), &resp.Diagnostics)
+	}
+
+	resp.Diagnostics.Append(resp.Plan.Set(ctx, &data)...)
+}
+
+func (r *resourceUtilitiesDirectorySync) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data DirectorySync
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r.sync(ctx, &data, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *resourceUtilitiesDirectorySync) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data DirectorySync
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	expected := map[string]string{}
+	resp.Diagnostics.Append(data.Manifest.ElementsAs(ctx, &expected, false)...)
+	options := data.options(ctx, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	destination := data.DestinationDir.ValueString()
+	entries, err := scanSyncTree(destination, options)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error Scanning Destination Directory",
+			fmt.Sprintf("Failed to scan the destination directory '%s': %v", destination, err),
+		)
+		return
+	}
+
+	// Extra files in the destination are only drift when they would be deleted
+	actual := syncManifest(entries)
+	if !data.DeleteExtraneous.ValueBool() {
+		for key := range actual {
+			if _, ok := expected[key]; !ok {
+				delete(actual, key)
+			}
+		}
+	}
+
+	var drifted []string
+	for key, digest := range expected {
+		if actual[key] != digest {
+			drifted = append(drifted, key)
+		}
+	}
+	for key := range actual {
+		if _, ok := expected[key]; !ok {
+			drifted = append(drifted, key)
+		}
+	}
+
+	if len(drifted) > 0 {
+		sort.Strings(drifted)
+		resp.Diagnostics.AddWarning(
+			"Sync Drift Detected",
+			fmt.Sprintf("The entries %s of '%s' no longer match the source, marking the resource for update.", strings.Join(drifted, ", "), destination),
+		)
+		data.setManifest(ctx, actual, &resp.Diagnostics)
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *resourceUtilitiesDirectorySync) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state DirectorySync
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r.sync(ctx, &plan, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The destination was moved, remove the files synchronized to the previous one
+	if previous := state.DestinationDir.ValueString(); previous != plan.DestinationDir.ValueString() {
+		r.remove(ctx, state, &resp.Diagnostics)
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *resourceUtilitiesDirectorySync) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data DirectorySync
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r.remove(ctx, data, &resp.Diagnostics)
+
+	resp.State.RemoveResource(ctx)
+}
+
+// sync mirrors the source directory into the destination directory and records the resulting
+// manifest in data.
+func (r *resourceUtilitiesDirectorySync) sync(ctx context.Context, data *DirectorySync, diagnostics *diag.Diagnostics) {
+	source := data.SourceDir.ValueString()
+	destination := data.DestinationDir.ValueString()
+
+	// Refuse destinations that the provider's path policy does not allow
+	if err := r.policy.checkAllowed(destination); err != nil {
+		diagnostics.AddError(
+			"Destination Not Allowed",
+			fmt.Sprintf("Refusing to synchronize to '%s': %v", destination, err),
+		)
+		return
+	}
+
+	if info, err := os.Stat(source); err != nil || !info.IsDir() {
+		diagnostics.AddError(
+			"Source Directory Not Found",
+			fmt.Sprintf("The source directory '%s' does not exist or is not a directory.", source),
+		)
+		return
+	}
+
+	options := data.options(ctx, diagnostics)
+	if diagnostics.HasError() {
+		return
+	}
+
+	sourceEntries, err := scanSyncTree(source, options)
+	if err != nil {
+		diagnostics.AddError(
+			"Error Scanning Source Directory",
+			fmt.Sprintf("Failed to scan the source directory '%s': %v", source, err),
+		)
+		return
+	}
+	destinationEntries, err := scanSyncTree(destination, options)
+	if err != nil {
+		diagnostics.AddError(
+			"Error Scanning Destination Directory",
+			fmt.Sprintf("Failed to scan the destination directory '%s': %v", destination, err),
+		)
+		return
+	}
+
+	if err := os.MkdirAll(destination, 0755); err != nil {
+		diagnostics.AddError(
+			"Error Creating Directory",
+			fmt.Sprintf("Failed to create directory '%s': %v", destination, err),
+		)
+		return
+	}
+
+	result, err := syncTree(destination, sourceEntries, destinationEntries, data.DeleteExtraneous.ValueBool(), options)
+	if err != nil {
+		diagnostics.AddError(
+			"Error Synchronizing Directory",
+			fmt.Sprintf("Failed to synchronize '%s' to '%s': %v", source, destination, err),
+		)
+		return
+	}
+
+	tflog.Debug(ctx, "Synchronized directory", map[string]interface{}{
+		"source":      source,
+		"destination": destination,
+		"copied":      result.Copied,
+		"deleted":     result.Deleted,
+	})
+
+	data.setManifest(ctx, syncManifest(sourceEntries), diagnostics)
+}
+
+// remove deletes the synchronized files, then the synchronized directories that are empty.
+// Files added to the destination outside of Terraform are kept.
+func (r *resourceUtilitiesDirectorySync) remove(ctx context.Context, data DirectorySync, diagnostics *diag.Diagnostics) {
+	destination := data.DestinationDir.ValueString()
+	if r.policy.isProtected(destination) {
+		tflog.Warn(ctx, "Attempted to delete from a protected path, skipping deletion", map[string]interface{}{"path": destination})
+		return
+	}
+
+	manifest := map[string]string{}
+	diagnostics.Append(data.Manifest.ElementsAs(ctx, &manifest, false)...)
+	if diagnostics.HasError() {
+		return
+	}
+
+	// Reverse lexical order removes the contents of a directory before the directory itself
+	keys := sortedKeys(manifest)
+	for i := len(keys) - 1; i >= 0; i-- {
+		p := templateDestination(destination, strings.TrimSuffix(keys[i], "/"))
+
+		if strings.HasSuffix(keys[i], "/") {
+			if entries, err := os.ReadDir(p); err != nil || len(entries) > 0 {
+				continue
+			}
+		}
+
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			diagnostics.AddWarning(
+				"File Deletion Failed",
+				fmt.Sprintf("Could not delete file '%s': %v", p, err),
+			)
+		}
+	}
+}
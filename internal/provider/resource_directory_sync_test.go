@@ -0,0 +1,107 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+)
+
+func TestResourceUtilitiesDirectorySync(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destinationDir := filepath.Join(tempDir, "destination")
+
+	requireNoError(t, os.MkdirAll(filepath.Join(sourceDir, "conf.d"), 0755))
+	requireNoError(t, os.MkdirAll(filepath.Join(sourceDir, ".git"), 0755))
+	requireNoError(t, os.WriteFile(filepath.Join(sourceDir, "app.conf"), []byte("name = web\n"), 0644))
+	requireNoError(t, os.WriteFile(filepath.Join(sourceDir, "conf.d", "site.conf"), []byte("listen = 80\n"), 0644))
+	requireNoError(t, os.WriteFile(filepath.Join(sourceDir, "conf.d", "site.conf.tmp"), []byte("scratch\n"), 0644))
+	requireNoError(t, os.WriteFile(filepath.Join(sourceDir, ".git", "HEAD"), []byte("ref: refs/heads/main\n"), 0644))
+
+	requireNoError(t, os.MkdirAll(destinationDir, 0755))
+	requireNoError(t, os.WriteFile(filepath.Join(destinationDir, "stale.conf"), []byte("stale\n"), 0644))
+
+	config := fmt.Sprintf(`
+		resource "utilities_directory_sync" "example" {
+			source_dir        = "%s"
+			destination_dir   = "%s"
+			delete_extraneous = true
+			exclude           = [".git", "**/*.tmp"]
+			parallelism       = 2
+		}
+	`, sourceDir, destinationDir)
+
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: TestAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("utilities_directory_sync.example", "file_count", "2"),
+					resource.TestCheckResourceAttr("utilities_directory_sync.example", "manifest.%", "3"),
+					resource.TestCheckResourceAttr("utilities_directory_sync.example", "manifest.conf.d/", "dir"),
+					resource.TestCheckResourceAttrSet("utilities_directory_sync.example", "manifest.conf.d/site.conf"),
+					func(s *terraform.State) error {
+						content, err := os.ReadFile(filepath.Join(destinationDir, "conf.d", "site.conf"))
+						if err != nil {
+							return err
+						}
+						if string(content) != "listen = 80\n" {
+							return fmt.Errorf("unexpected synchronized content '%s'", content)
+						}
+						for _, name := range []string{"stale.conf", ".git", filepath.Join("conf.d", "site.conf.tmp")} {
+							if _, err := os.Stat(filepath.Join(destinationDir, name)); !os.IsNotExist(err) {
+								return fmt.Errorf("expected '%s' not to exist in the destination", name)
+							}
+						}
+
+						return nil
+					},
+				),
+			},
+			{
+				// A file changed in the source is detected during plan
+				PreConfig: func() {
+					requireNoError(t, os.WriteFile(filepath.Join(sourceDir, "app.conf"), []byte("name = api\n"), 0644))
+				},
+				Config:             config,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				Config: config,
+				Check: func(s *terraform.State) error {
+					content, err := os.ReadFile(filepath.Join(destinationDir, "app.conf"))
+					if err != nil {
+						return err
+					}
+					if string(content) != "name = api\n" {
+						return fmt.Errorf("expected the changed file to be copied, got '%s'", content)
+					}
+
+					return nil
+				},
+			},
+			{
+				// A file added to the destination is drift, since extraneous files are deleted
+				PreConfig: func() {
+					requireNoError(t, os.WriteFile(filepath.Join(destinationDir, "extra.conf"), []byte("extra\n"), 0644))
+				},
+				Config:             config,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
@@ -0,0 +1,802 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ resource.Resource               = (*resourceUtilitiesExtractPackage)(nil)
+	_ resource.ResourceWithConfigure  = (*resourceUtilitiesExtractPackage)(nil)
+	_ resource.ResourceWithModifyPlan = (*resourceUtilitiesExtractPackage)(nil)
+)
+
+var packageArchive = archiveKind{Name: "Package", Noun: "package", Extraction: "package extraction"}
+
+type resourceUtilitiesExtractPackage struct {
+	policy *pathPolicy
+}
+
+type ExtractPackage struct {
+	Source             types.String    `tfsdk:"source"`
+	Url                types.String    `tfsdk:"url"`
+	Destination        types.String    `tfsdk:"destination"`
+	CreatedFiles       types.List      `tfsdk:"created_files"`
+	FileHash           types.String    `tfsdk:"file_hash"`
+	DestinationCreated types.Bool      `tfsdk:"destination_created"`
+	Owner              types.String    `tfsdk:"owner"`
+	Group              types.String    `tfsdk:"group"`
+	FileMode           types.String    `tfsdk:"file_mode"`
+	DirMode            types.String    `tfsdk:"dir_mode"`
+	Signature          types.String    `tfsdk:"signature"`
+	SignatureURL       types.String    `tfsdk:"signature_url"`
+	PublicKey          types.String    `tfsdk:"public_key"`
+	ManifestMode       types.String    `tfsdk:"manifest_mode"`
+	ManifestPath       types.String    `tfsdk:"manifest_path"`
+	ManifestSHA256     types.String    `tfsdk:"manifest_sha256"`
+	FileCount          types.Int64     `tfsdk:"file_count"`
+	CreatedFilesGzip   types.String    `tfsdk:"created_files_compressed"`
+	ExtractNested      *ExtractNested  `tfsdk:"extract_nested"`
+	Rename             []ExtractRename `tfsdk:"rename"`
+	PreserveXattrs     types.Bool      `tfsdk:"preserve_xattrs"`
+	EntriesExtracted   types.Int64     `tfsdk:"entries_extracted"`
+	BytesWritten       types.Int64     `tfsdk:"bytes_written"`
+	PhysicalBytes      types.Int64     `tfsdk:"physical_bytes"`
+	SkippedEntries     types.Int64     `tfsdk:"skipped_entries"`
+	DurationMs         types.Int64     `tfsdk:"duration_ms"`
+	Format             types.String    `tfsdk:"format"`
+	PackageName        types.String    `tfsdk:"package_name"`
+	PackageVersion     types.String    `tfsdk:"package_version"`
+	PackageArch        types.String    `tfsdk:"package_arch"`
+	PackageDeps        types.List      `tfsdk:"package_dependencies"`
+}
+
+// options compiles the settings applied to every extracted entry.
+func (d ExtractPackage) options() (extractOptions, error) {
+	renamer, err := newEntryRenamer(d.Rename)
+	if err != nil {
+		return extractOptions{}, err
+	}
+
+	options := extractOptions{Rename: renamer}
+	if d.PreserveXattrs.ValueBool() {
+		options.Xattrs = &extractXattrs{}
+	}
+
+	return options, nil
+}
+
+func (d ExtractPackage) manifest() extractManifest {
+	return extractManifest{
+		CreatedFiles: d.CreatedFiles,
+		Compressed:   d.CreatedFilesGzip,
+		Digest:       d.ManifestSHA256,
+		FileCount:    d.FileCount,
+	}
+}
+
+// setMetadata stores the metadata read from the package.
+func (d *ExtractPackage) setMetadata(metadata packageMetadata) {
+	dependencies := []attr.Value{}
+	for _, dependency := range metadata.Dependencies {
+		dependencies = append(dependencies, types.StringValue(dependency))
+	}
+
+	d.PackageName = types.StringValue(metadata.Name)
+	d.PackageVersion = types.StringValue(metadata.Version)
+	d.PackageArch = types.StringValue(metadata.Arch)
+	d.PackageDeps = types.ListValueMust(types.StringType, dependencies)
+}
+
+func (d *ExtractPackage) setManifest(manifest extractManifest) {
+	d.CreatedFiles = manifest.CreatedFiles
+	d.CreatedFilesGzip = manifest.Compressed
+	d.ManifestSHA256 = manifest.Digest
+	d.FileCount = manifest.FileCount
+}
+
+func (d *ExtractPackage) setReport(report *extractReport) {
+	d.EntriesExtracted = types.Int64Value(report.EntriesExtracted)
+	d.BytesWritten = types.Int64Value(report.BytesWritten)
+	d.PhysicalBytes = types.Int64Value(report.PhysicalBytes)
+	d.SkippedEntries = types.Int64Value(report.SkippedEntries)
+	d.DurationMs = types.Int64Value(report.Duration.Milliseconds())
+}
+
+// target returns the shared state that describes the extracted entries.
+func (d ExtractPackage) target() extractTarget {
+	return extractTarget{
+		Destination:        d.Destination.ValueString(),
+		DestinationCreated: d.DestinationCreated.ValueBool(),
+		Ownership:          newExtractOwnership(d.Owner, d.Group, d.FileMode, d.DirMode),
+		ManifestMode:       d.ManifestMode.ValueString(),
+		ManifestPath:       d.ManifestPath,
+		Manifest:           d.manifest(),
+	}
+}
+
+// setOwnershipDrift stores the observed value of every ownership setting that drifted.
+func (d *ExtractPackage) setOwnershipDrift(drift extractOwnership) {
+	d.Owner = driftedValue(d.Owner, drift.Owner)
+	d.Group = driftedValue(d.Group, drift.Group)
+	d.FileMode = driftedValue(d.FileMode, drift.FileMode)
+	d.DirMode = driftedValue(d.DirMode, drift.DirMode)
+}
+
+// NewResourceUtilitiesExtractPackage creates a new instance of the resource.
+func NewResourceUtilitiesExtractPackage() resource.Resource {
+	return &resourceUtilitiesExtractPackage{}
+}
+
+func (r *resourceUtilitiesExtractPackage) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.policy = providerPathPolicy(req.ProviderData)
+}
+
+// Metadata sets the resource type name.
+func (r *resourceUtilitiesExtractPackage) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = "utilities_extract_package"
+}
+
+func (r *resourceUtilitiesExtractPackage) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data ExtractPackage
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var source string
+	var url string
+
+	// If source is set, get its value
+	if !data.Source.IsNull() {
+		source = data.Source.ValueString()
+	}
+
+	// If url is set, get its value
+	if !data.Url.IsNull() {
+		url = data.Url.ValueString()
+	}
+
+	destination := data.Destination.ValueString()
+
+	// Refuse destinations that the provider's path policy does not allow
+	if err := r.policy.checkAllowed(destination); err != nil {
+		resp.Diagnostics.AddError(
+			"Destination Not Allowed",
+			fmt.Sprintf("Refusing to extract to '%s': %v", destination, err),
+		)
+		return
+	}
+
+	// Calculate the hash of the source file if the source is provided
+	var fileHash string
+	var err error
+	if source != "" {
+		fileHash, err = calculateFileHash(source)
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"File Hash Calculation Failed",
+				fmt.Sprintf("Error calculating hash for file '%s': %v", source, err),
+			)
+			return
+		}
+	}
+
+	// Compile the settings applied to every extracted entry
+	options, err := data.options()
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Invalid Rename Rule",
+			err.Error(),
+		)
+		return
+	}
+	ctx, options.Report = newExtractReport(ctx)
+
+	// Extract the package based on source or URL
+	var createdFiles []string
+	var metadata packageMetadata
+	var destinationCreated bool
+	format := data.Format.ValueString()
+	if source != "" {
+		createdFiles, metadata, destinationCreated, err = r.validateAndExtractPackage(ctx, source, format, destination, options, &resp.Diagnostics)
+	} else if url != "" {
+		signature := newArchiveSignature(data.Signature, data.SignatureURL, data.PublicKey)
+		createdFiles, metadata, destinationCreated, err = r.validateAndExtractPackageFromURL(ctx, url, format, destination, signature, options, &resp.Diagnostics)
+	}
+
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Package Extraction Failed",
+			fmt.Sprintf("Error extracting package from '%s' to '%s': %v", source, destination, err),
+		)
+		return
+	}
+
+	// Apply ownership, extended attributes and the manifest to the extracted entries
+	target := data.target()
+	target.DestinationCreated = destinationCreated
+	manifest, ok := finishExtraction(ctx, target, createdFiles, data.ExtractNested, options, &resp.Diagnostics)
+	if !ok {
+		return
+	}
+
+	// Update state with hash and created files
+	stateData := ExtractPackage{
+		Source:             data.Source,
+		Url:                data.Url,
+		Destination:        data.Destination,
+		FileHash:           types.StringValue(fileHash),
+		DestinationCreated: types.BoolValue(destinationCreated),
+		Owner:              data.Owner,
+		Group:              data.Group,
+		FileMode:           data.FileMode,
+		DirMode:            data.DirMode,
+		Signature:          data.Signature,
+		SignatureURL:       data.SignatureURL,
+		PublicKey:          data.PublicKey,
+		ManifestMode:       data.ManifestMode,
+		ManifestPath:       data.ManifestPath,
+		ExtractNested:      data.ExtractNested,
+		PreserveXattrs:     data.PreserveXattrs,
+		Rename:             data.Rename,
+		Format:             data.Format,
+	}
+	stateData.setManifest(manifest)
+	stateData.setReport(options.Report)
+	stateData.setMetadata(metadata)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &stateData)...)
+}
+
+func (r *resourceUtilitiesExtractPackage) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data ExtractPackage
+
+	// Load the current state
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Delete the extracted files, children before their parents, and the sidecar manifest
+	deleteExtractTarget(ctx, data.target(), &resp.Diagnostics)
+
+	// Clear the state to remove the resource from Terraform's state
+	resp.State.RemoveResource(ctx)
+}
+
+// ModifyPlan reads the metadata and listing of a local source package so that they are known at
+// plan time.
+func (r *resourceUtilitiesExtractPackage) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to plan when the resource is being destroyed
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var data ExtractPackage
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// A manifest that drifted during refresh forces the files to be extracted again
+	if !req.State.Raw.IsNull() {
+		var state ExtractPackage
+		resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		if state.ManifestSHA256.ValueString() == "" && !state.ManifestSHA256.IsNull() {
+			data.ManifestSHA256 = types.StringUnknown()
+			resp.Diagnostics.Append(resp.Plan.Set(ctx, &data)...)
+		}
+	}
+
+	// Invalid rename patterns are reported at plan time
+	options, err := data.options()
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Invalid Rename Rule",
+			err.Error(),
+		)
+		return
+	}
+
+	// The package can only be read for local sources known at plan time
+	if data.Source.IsNull() || data.Source.IsUnknown() || data.Destination.IsUnknown() || data.Format.IsUnknown() {
+		return
+	}
+
+	source := data.Source.ValueString()
+	if _, err := os.Stat(source); err != nil {
+		// The package may be created by another resource during apply
+		return
+	}
+
+	metadata, createdFiles, err := listPackageFile(source, data.Format.ValueString(), data.Destination.ValueString(), options)
+	if err != nil {
+		resp.Diagnostics.AddWarning(
+			"Package Listing Failed",
+			fmt.Sprintf("Unable to read the contents of '%s' during plan, created_files will be known after apply: %v", source, err),
+		)
+		return
+	}
+	data.setMetadata(metadata)
+
+	// The listing does not include the contents of nested archives
+	if data.ExtractNested == nil {
+		manifest, err := newExtractManifest(data.ManifestMode.ValueString(), createdFiles)
+		if err != nil {
+			return
+		}
+		data.setManifest(manifest)
+	}
+
+	resp.Diagnostics.Append(resp.Plan.Set(ctx, &data)...)
+}
+
+func (r *resourceUtilitiesExtractPackage) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data ExtractPackage
+
+	// Load the current state
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Detect drift of a local source
+	data.FileHash = sourceHashDrift(data.Source, data.Url, data.FileHash, "package", &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Detect drift in the manifest and in the ownership and permissions of the extracted entries
+	manifest, drift := extractTargetDrift(ctx, data.target(), &resp.Diagnostics)
+	data.ManifestSHA256 = manifest.Digest
+	data.FileCount = manifest.FileCount
+	data.setOwnershipDrift(drift)
+
+	// Update the state at the end
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *resourceUtilitiesExtractPackage) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	permissionsRegex := regexp.MustCompile(`^0[0-7]{3}$`)
+
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Extracts the payload of a Debian (`.deb`), RPM (`.rpm`) or cpio package to a specified directory without dpkg or rpm. Symbolic and hard links are extracted when their targets stay inside the destination. Other entries in the payload, such as device nodes and fifos, are skipped with a warning.",
+		Attributes: map[string]schema.Attribute{
+			"source": schema.StringAttribute{
+				Optional:    true,
+				Description: "The path to the source package to be extracted.",
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(path.MatchRelative().AtParent().AtName("url")),
+				},
+			},
+			"url": schema.StringAttribute{
+				Optional:    true,
+				Description: "The URL to the source package to be extracted. This URL must point to a valid deb, rpm or cpio file.",
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(path.MatchRelative().AtParent().AtName("source")),
+					stringvalidator.RegexMatches(regexp.MustCompile(`^https?://.+\.(deb|rpm|cpio)$`), "must be a valid HTTP(S) URL ending with '.deb', '.rpm' or '.cpio'"),
+				},
+			},
+			"destination": schema.StringAttribute{
+				Required:    true,
+				Description: "The destination directory where the package payload will be extracted.",
+			},
+			"created_files": schema.ListAttribute{
+				ElementType: types.StringType,
+				Computed:    true,
+				Description: "A list of paths to the files created during package extraction. Known at plan time when `source` is a local archive. Empty unless `manifest_mode` is `state`.",
+			},
+			"created_files_compressed": schema.StringAttribute{
+				Computed:    true,
+				Description: "The list of created files, gzip-compressed and base64-encoded, when `manifest_mode` is `compressed`.",
+			},
+			"manifest_mode": schema.StringAttribute{
+				Optional:    true,
+				Description: "How the list of created files is recorded: `state` (default) stores it in `created_files`, `sidecar` writes it to `manifest_path` and `compressed` stores it in `created_files_compressed`.",
+				Validators: []validator.String{
+					stringvalidator.OneOf(manifestModeState, manifestModeSidecar, manifestModeCompressed),
+				},
+			},
+			"manifest_path": schema.StringAttribute{
+				Optional:    true,
+				Description: "The sidecar manifest file used when `manifest_mode` is `sidecar`. Defaults to `.utilities-manifest` inside the destination.",
+			},
+			"manifest_sha256": schema.StringAttribute{
+				Computed:    true,
+				Description: "The SHA-256 digest of the list of created files.",
+			},
+			"file_count": schema.Int64Attribute{
+				Computed:    true,
+				Description: "The number of files created during package extraction.",
+			},
+			"preserve_xattrs": schema.BoolAttribute{
+				Optional:    true,
+				Description: "Restore extended attributes and POSIX ACLs recorded in PAX headers, such as file capabilities. Attributes that cannot be set produce warnings.",
+			},
+			"entries_extracted": schema.Int64Attribute{
+				Computed:    true,
+				Description: "The number of entries written by the last extraction, including nested archives.",
+			},
+			"bytes_written": schema.Int64Attribute{
+				Computed:    true,
+				Description: "The logical size of the files written by the last extraction, counting the holes of sparse files.",
+			},
+			"physical_bytes": schema.Int64Attribute{
+				Computed:    true,
+				Description: "The number of bytes physically written by the last extraction. Holes in sparse files are not written.",
+			},
+			"skipped_entries": schema.Int64Attribute{
+				Computed:    true,
+				Description: "The number of entries skipped by the last extraction, such as entries dropped by a rename rule.",
+			},
+			"duration_ms": schema.Int64Attribute{
+				Computed:    true,
+				Description: "The time taken by the last extraction, in milliseconds.",
+			},
+			"file_hash": schema.StringAttribute{
+				Computed:    true,
+				Description: "The hash of the source package, used for integrity verification.",
+			},
+			"destination_created": schema.BoolAttribute{
+				Computed:    true,
+				Description: "Indicates whether the destination directory was created by the resource.",
+			},
+			"owner": schema.StringAttribute{
+				Optional:    true,
+				Description: "User to own every extracted file and directory.",
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"group": schema.StringAttribute{
+				Optional:    true,
+				Description: "Group to own every extracted file and directory.",
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"file_mode": schema.StringAttribute{
+				Optional:    true,
+				Description: "Permissions to set on every extracted file, in octal format (e.g., 0644).",
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(permissionsRegex, "must be a valid octal permission (e.g., 0644)"),
+				},
+			},
+			"dir_mode": schema.StringAttribute{
+				Optional:    true,
+				Description: "Permissions to set on every extracted directory, in octal format (e.g., 0755).",
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(permissionsRegex, "must be a valid octal permission (e.g., 0755)"),
+				},
+			},
+			"signature": schema.StringAttribute{
+				Optional:    true,
+				Description: "A detached OpenPGP or minisign signature of the package downloaded from `url`.",
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(path.MatchRelative().AtParent().AtName("signature_url")),
+					stringvalidator.AlsoRequires(path.MatchRelative().AtParent().AtName("url"), path.MatchRelative().AtParent().AtName("public_key")),
+				},
+			},
+			"signature_url": schema.StringAttribute{
+				Optional:    true,
+				Description: "The URL of a detached OpenPGP or minisign signature of the package downloaded from `url`.",
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(path.MatchRelative().AtParent().AtName("signature")),
+					stringvalidator.AlsoRequires(path.MatchRelative().AtParent().AtName("url"), path.MatchRelative().AtParent().AtName("public_key")),
+					stringvalidator.RegexMatches(regexp.MustCompile(`^https?://.+`), "must be a valid HTTP(S) URL"),
+				},
+			},
+			"format": schema.StringAttribute{
+				Optional:    true,
+				Description: "The package format: `deb`, `rpm`, `cpio` or `auto` (default) to detect it from the file contents.",
+				Validators: []validator.String{
+					stringvalidator.OneOf(packageFormatAuto, packageFormatDeb, packageFormatRPM, packageFormatCpio),
+				},
+			},
+			"package_name": schema.StringAttribute{
+				Computed:    true,
+				Description: "The name of the package. Empty for raw cpio archives.",
+			},
+			"package_version": schema.StringAttribute{
+				Computed:    true,
+				Description: "The version of the package. RPM versions include the release.",
+			},
+			"package_arch": schema.StringAttribute{
+				Computed:    true,
+				Description: "The architecture of the package.",
+			},
+			"package_dependencies": schema.ListAttribute{
+				ElementType: types.StringType,
+				Computed:    true,
+				Description: "The dependencies declared by the package.",
+			},
+			"extract_nested": extractNestedSchema(),
+			"rename":         renameSchema(),
+			"public_key": schema.StringAttribute{
+				Optional:    true,
+				Description: "The armored OpenPGP public key or minisign public key used to verify the signature. Extraction is blocked if verification fails.",
+				Validators: []validator.String{
+					stringvalidator.AlsoRequires(path.MatchRelative().AtParent().AtName("url")),
+				},
+			},
+		},
+	}
+}
+
+func (r *resourceUtilitiesExtractPackage) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var planData ExtractPackage
+	var stateData ExtractPackage
+
+	// Get plan and state
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &planData)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &stateData)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	source := planData.Source.ValueString()
+	url := planData.Url.ValueString()
+	destination := planData.Destination.ValueString()
+
+	// Refuse destinations that the provider's path policy does not allow
+	if err := r.policy.checkAllowed(destination); err != nil {
+		resp.Diagnostics.AddError(
+			"Destination Not Allowed",
+			fmt.Sprintf("Refusing to extract to '%s': %v", destination, err),
+		)
+		return
+	}
+
+	// If the source is a URL, skip file hash calculation
+	newFileHash := ""
+	if planData.Url.IsNull() {
+		// Calculate the new hash of the source file
+		var err error
+		newFileHash, err = calculateFileHash(source)
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"File Hash Calculation Failed",
+				fmt.Sprintf("Error calculating hash for file '%s': %v", source, err),
+			)
+			return
+		}
+	}
+
+	// Compare new hash with the existing state hash if hash is being used (not a URL)
+	unchanged := stateData.FileHash.ValueString() == newFileHash && stateData.Url.Equal(planData.Url) && stateData.Destination.Equal(planData.Destination) && stateData.ExtractNested.equal(planData.ExtractNested) && renameRulesEqual(stateData.Rename, planData.Rename) && stateData.Format.Equal(planData.Format)
+
+	// Restored extended attributes do not survive a change of owner, so the archive is extracted again
+	if planData.PreserveXattrs.ValueBool() && (!stateData.PreserveXattrs.ValueBool() || !stateData.Owner.Equal(planData.Owner) || !stateData.Group.Equal(planData.Group)) {
+		unchanged = false
+	}
+
+	// A planned manifest that differs from the state means the archive contents changed
+	if !planData.ManifestSHA256.IsUnknown() && !planData.ManifestSHA256.Equal(stateData.ManifestSHA256) {
+		unchanged = false
+	}
+
+	var createdFiles []string
+	if unchanged {
+		var err error
+		createdFiles, err = readExtractManifest(ctx, stateData.ManifestMode.ValueString(), manifestPathFor(stateData.Destination.ValueString(), stateData.ManifestPath), stateData.manifest())
+		if err != nil {
+			unchanged = false
+		}
+	}
+
+	if unchanged {
+		// No change, re-apply ownership without re-extraction
+		target := planData.target()
+		target.DestinationCreated = stateData.DestinationCreated.ValueBool()
+		manifest, ok := applyExtractTarget(ctx, target, createdFiles, nil, &resp.Diagnostics)
+		if !ok {
+			return
+		}
+
+		stateData.Source = planData.Source
+		stateData.Owner = planData.Owner
+		stateData.Group = planData.Group
+		stateData.FileMode = planData.FileMode
+		stateData.DirMode = planData.DirMode
+		stateData.Signature = planData.Signature
+		stateData.SignatureURL = planData.SignatureURL
+		stateData.PublicKey = planData.PublicKey
+		stateData.PreserveXattrs = planData.PreserveXattrs
+
+		stateData.ManifestMode = planData.ManifestMode
+		stateData.ManifestPath = planData.ManifestPath
+		stateData.setManifest(manifest)
+
+		resp.Diagnostics.Append(resp.State.Set(ctx, &stateData)...)
+		return
+	}
+
+	// Extract the package since the hash has changed
+	var metadata packageMetadata
+	var destinationCreated bool
+	options, err := planData.options()
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Invalid Rename Rule",
+			err.Error(),
+		)
+		return
+	}
+	ctx, options.Report = newExtractReport(ctx)
+	if url != "" {
+		signature := newArchiveSignature(planData.Signature, planData.SignatureURL, planData.PublicKey)
+		createdFiles, metadata, destinationCreated, err = r.validateAndExtractPackageFromURL(ctx, url, planData.Format.ValueString(), destination, signature, options, &resp.Diagnostics)
+	} else {
+		createdFiles, metadata, destinationCreated, err = r.validateAndExtractPackage(ctx, source, planData.Format.ValueString(), destination, options, &resp.Diagnostics)
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Package Extraction Failed",
+			fmt.Sprintf("Error extracting package from '%s' to '%s': %v", source, destination, err),
+		)
+		return
+	}
+
+	// Apply ownership, extended attributes and the manifest to the extracted entries
+	target := planData.target()
+	target.DestinationCreated = destinationCreated
+	manifest, ok := finishExtraction(ctx, target, createdFiles, planData.ExtractNested, options, &resp.Diagnostics)
+	if !ok {
+		return
+	}
+
+	// Update state with new hash and created files
+	updatedStateData := ExtractPackage{
+		Source:             planData.Source,
+		Url:                planData.Url,
+		Destination:        planData.Destination,
+		FileHash:           types.StringValue(newFileHash),
+		DestinationCreated: types.BoolValue(destinationCreated),
+		Owner:              planData.Owner,
+		Group:              planData.Group,
+		FileMode:           planData.FileMode,
+		DirMode:            planData.DirMode,
+		Signature:          planData.Signature,
+		SignatureURL:       planData.SignatureURL,
+		PublicKey:          planData.PublicKey,
+		ManifestMode:       planData.ManifestMode,
+		ManifestPath:       planData.ManifestPath,
+		ExtractNested:      planData.ExtractNested,
+		PreserveXattrs:     planData.PreserveXattrs,
+		Rename:             planData.Rename,
+		Format:             planData.Format,
+	}
+	updatedStateData.setManifest(manifest)
+	updatedStateData.setReport(options.Report)
+	updatedStateData.setMetadata(metadata)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &updatedStateData)...)
+}
+
+func (r *resourceUtilitiesExtractPackage) validateAndExtractPackage(ctx context.Context, source, format, destination string, options extractOptions, diagnostics *diag.Diagnostics) ([]string, packageMetadata, bool, error) {
+	var metadata packageMetadata
+	createdFiles, destinationCreated, err := extractArchive(ctx, packageArchive, source, destination, diagnostics, func(createdFiles *[]string) error {
+		var err error
+		metadata, err = extractPackageFile(ctx, source, format, destination, options, diagnostics, createdFiles)
+		return err
+	})
+
+	return createdFiles, metadata, destinationCreated, err
+}
+
+// extractPackageFile extracts the payload of a deb, rpm or cpio package to a specified destination
+// and returns the package metadata.
+func extractPackageFile(ctx context.Context, source, format, destination string, options extractOptions, diagnostics *diag.Diagnostics, createdFiles *[]string) (packageMetadata, error) {
+	metadata, err := walkPackage(source, format, func(header *tar.Header, reader io.Reader) error {
+		select {
+		case <-ctx.Done():
+			diagnostics.AddError(
+				"Operation Canceled",
+				"Context was canceled during package extraction.",
+			)
+			return ctx.Err()
+		default:
+			// Continue processing
+		}
+
+		if !isPackageEntrySupported(header) {
+			options.Report.entrySkipped(ctx, header.Name, fmt.Sprintf("unsupported entry type '%c'", header.Typeflag))
+			diagnostics.AddWarning(
+				"Package Entry Skipped",
+				fmt.Sprintf("The entry '%s' of package '%s' has the unsupported type '%c' and was not extracted.", header.Name, source, header.Typeflag),
+			)
+			return nil
+		}
+
+		// Extract each entry
+		if err := extractTarGzEntry(ctx, header, reader, destination, options, diagnostics, createdFiles); err != nil {
+			diagnostics.AddError(
+				"File Extraction Failed",
+				fmt.Sprintf("Failed to extract file '%s': %v", header.Name, err),
+			)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		diagnostics.AddError(
+			"Package Read Failed",
+			fmt.Sprintf("Failed to read package '%s': %v", source, err),
+		)
+		return metadata, err
+	}
+
+	return metadata, nil
+}
+
+// listPackageFile returns the metadata of a package and the paths that extracting its payload to
+// destination would create, in the same order as extractPackageFile records them.
+func listPackageFile(source, format, destination string, options extractOptions) (packageMetadata, []string, error) {
+	createdFiles := []string{}
+	metadata, err := walkPackage(source, format, func(header *tar.Header, reader io.Reader) error {
+		if !isPackageEntrySupported(header) {
+			return nil
+		}
+
+		name, ok := options.Rename.apply(header.Name)
+		if !ok {
+			return nil
+		}
+
+		destPath, err := entryDestination(destination, name)
+		if err != nil {
+			return err
+		}
+		if header.Typeflag == tar.TypeDir && destPath == destination {
+			return nil
+		}
+		createdFiles = append(createdFiles, destPath)
+		return nil
+	})
+	if err != nil {
+		return metadata, nil, err
+	}
+
+	return metadata, createdFiles, nil
+}
+
+// isPackageEntrySupported reports whether a payload entry is extracted. Other entries, such as
+// device nodes, fifos and global headers, are skipped with a warning.
+func isPackageEntrySupported(header *tar.Header) bool {
+	switch header.Typeflag {
+	case tar.TypeDir, tar.TypeReg, tar.TypeSymlink, tar.TypeLink:
+		return true
+	default:
+		return false
+	}
+}
+
+func (r *resourceUtilitiesExtractPackage) validateAndExtractPackageFromURL(ctx context.Context, url, format, destination string, signature archiveSignature, options extractOptions, diagnostics *diag.Diagnostics) ([]string, packageMetadata, bool, error) {
+	tmpFile, err := downloadArchive(ctx, packageArchive, url, "downloaded-package-*", signature, diagnostics)
+	if err != nil {
+		return nil, packageMetadata{}, false, err
+	}
+	defer func() { _ = os.Remove(tmpFile) }()
+
+	return r.validateAndExtractPackage(ctx, tmpFile, format, destination, options, diagnostics)
+}
@@ -0,0 +1,205 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+)
+
+func TestResourceUtilitiesExtractPackageDeb(t *testing.T) {
+	tempDir := t.TempDir()
+	debFilePath := filepath.Join(tempDir, "hello_1.2-3_amd64.deb")
+	extractedDir := filepath.Join(tempDir, "extracted")
+
+	writeTestDeb(t, debFilePath,
+		"Package: hello\nVersion: 1.2-3\nArchitecture: amd64\nDepends: libc6 (>= 2.34), libfoo | libbar\n",
+		map[string]string{
+			"usr/bin/hello": "#!/bin/sh\n",
+		},
+	)
+
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: TestAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+					resource "utilities_extract_package" "example" {
+						destination = "%s"
+						source      = "%s"
+					}
+					`, extractedDir, debFilePath),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("utilities_extract_package.example", "package_name", "hello"),
+					resource.TestCheckResourceAttr("utilities_extract_package.example", "package_version", "1.2-3"),
+					resource.TestCheckResourceAttr("utilities_extract_package.example", "package_arch", "amd64"),
+					resource.TestCheckResourceAttr("utilities_extract_package.example", "package_dependencies.#", "2"),
+					resource.TestCheckResourceAttr("utilities_extract_package.example", "package_dependencies.0", "libc6 (>= 2.34)"),
+					resource.TestCheckResourceAttr("utilities_extract_package.example", "package_dependencies.1", "libfoo | libbar"),
+					resource.TestCheckResourceAttr("utilities_extract_package.example", "created_files.#", "1"),
+					resource.TestCheckResourceAttr("utilities_extract_package.example", "created_files.0", filepath.Join(extractedDir, "usr", "bin", "hello")),
+				),
+			},
+		},
+	})
+}
+
+// writeTestDeb writes a Debian package with the given control file and data entries.
+func writeTestDeb(t *testing.T, path, control string, entries map[string]string) {
+	t.Helper()
+
+	controlPath := filepath.Join(t.TempDir(), "control.tar.gz")
+	writeTestTarGz(t, controlPath, map[string]string{"./control": control})
+	dataPath := filepath.Join(t.TempDir(), "data.tar.gz")
+	writeTestTarGz(t, dataPath, entries)
+
+	var buf bytes.Buffer
+	buf.WriteString(arMagic)
+	for _, member := range []struct {
+		name string
+		path string
+	}{
+		{name: "debian-binary"},
+		{name: "control.tar.gz", path: controlPath},
+		{name: "data.tar.gz", path: dataPath},
+	} {
+		content := []byte("2.0\n")
+		if member.path != "" {
+			var err error
+			content, err = os.ReadFile(member.path)
+			requireNoError(t, err)
+		}
+
+		fmt.Fprintf(&buf, "%-16s%-12d%-6d%-6d%-8s%-10d`\n", member.name, 0, 0, 0, "100644", len(content))
+		buf.Write(content)
+		if len(content)%2 == 1 {
+			buf.WriteByte('\n')
+		}
+	}
+
+	requireNoError(t, os.WriteFile(path, buf.Bytes(), 0644))
+}
+
+func TestResourceUtilitiesExtractPackageLinks(t *testing.T) {
+	tempDir := t.TempDir()
+	cpioFilePath := filepath.Join(tempDir, "payload.cpio")
+	unsafeFilePath := filepath.Join(tempDir, "unsafe.cpio")
+	extractedDir := filepath.Join(tempDir, "extracted")
+	libDir := filepath.Join(extractedDir, "usr", "lib")
+
+	// The contents of the hard linked library come with its last entry, as rpm writes them
+	writeTestCpio(t, cpioFilePath, []testCpioEntry{
+		{name: "./usr/lib", mode: 0040755, ino: 1, nlink: 2},
+		{name: "./usr/lib/libfoo.so.1", mode: 0100644, ino: 2, nlink: 2},
+		{name: "./usr/lib/libfoo.so.1.0", mode: 0100644, ino: 2, nlink: 2, data: "library"},
+		{name: "./usr/lib/libfoo.so", mode: 0120777, ino: 3, nlink: 1, data: "libfoo.so.1"},
+	})
+	writeTestCpio(t, unsafeFilePath, []testCpioEntry{
+		{name: "./etc", mode: 0120777, ino: 1, nlink: 1, data: "../../etc"},
+	})
+
+	config := func(source string) string {
+		return fmt.Sprintf(`
+			resource "utilities_extract_package" "example" {
+				destination = "%s"
+				source      = "%s"
+				format      = "cpio"
+			}
+			`, extractedDir, source)
+	}
+
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: TestAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config(cpioFilePath),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("utilities_extract_package.example", "created_files.#", "4"),
+					resource.TestCheckResourceAttr("utilities_extract_package.example", "skipped_entries", "0"),
+					func(s *terraform.State) error {
+						target, err := os.Readlink(filepath.Join(libDir, "libfoo.so"))
+						if err != nil {
+							return err
+						}
+						if target != "libfoo.so.1" {
+							return fmt.Errorf("expected link to libfoo.so.1, got %s", target)
+						}
+
+						first, err := os.Stat(filepath.Join(libDir, "libfoo.so.1"))
+						if err != nil {
+							return err
+						}
+						last, err := os.Stat(filepath.Join(libDir, "libfoo.so.1.0"))
+						if err != nil {
+							return err
+						}
+						if !os.SameFile(first, last) {
+							return fmt.Errorf("expected libfoo.so.1 and libfoo.so.1.0 to be hard linked")
+						}
+						content, err := os.ReadFile(filepath.Join(libDir, "libfoo.so.1"))
+						if err != nil {
+							return err
+						}
+						if string(content) != "library" {
+							return fmt.Errorf("expected the contents of the last link, got %q", content)
+						}
+
+						return nil
+					},
+				),
+			},
+			{
+				// Links that point outside the destination are refused
+				Config:      config(unsafeFilePath),
+				ExpectError: regexp.MustCompile("Unsafe Archive Entry"),
+			},
+		},
+	})
+}
+
+// testCpioEntry is an entry of a test cpio archive. The data of a symbolic link is its target.
+type testCpioEntry struct {
+	name  string
+	mode  int64
+	ino   int64
+	nlink int64
+	data  string
+}
+
+// writeTestCpio writes a cpio archive in the "newc" format.
+func writeTestCpio(t *testing.T, path string, entries []testCpioEntry) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	pad := func() {
+		for buf.Len()%4 != 0 {
+			buf.WriteByte(0)
+		}
+	}
+	for _, entry := range append(entries, testCpioEntry{name: cpioTrailer}) {
+		fmt.Fprintf(&buf, "%s%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x",
+			cpioMagicNewc, entry.ino, entry.mode, 0, 0, entry.nlink, 0, len(entry.data), 0, 0, 0, 0, len(entry.name)+1, 0)
+		buf.WriteString(entry.name)
+		buf.WriteByte(0)
+		pad()
+		buf.WriteString(entry.data)
+		pad()
+	}
+
+	requireNoError(t, os.WriteFile(path, buf.Bytes(), 0644))
+}
@@ -4,14 +4,29 @@
 package provider
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"encoding/base64"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"regexp"
+	"sort"
+	"strings"
 	"testing"
 
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
 	"github.com/google/uuid"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
 	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/hashicorp/terraform-plugin-testing/tfjsonpath"
 	"github.com/hashicorp/terraform-plugin-testing/tfversion"
 )
 
@@ -127,3 +142,485 @@ func TestResourceUtilitiesExtractTarGz(t *testing.T) {
 		},
 	})
 }
+
+func TestResourceUtilitiesExtractTarGzOwnership(t *testing.T) {
+	tempDir := t.TempDir()
+	tarGzFilePath := filepath.Join(tempDir, "sample.tar.gz")
+	extractedDir := filepath.Join(tempDir, "extracted")
+
+	writeTestTarGz(t, tarGzFilePath, map[string]string{
+		"sample/":          "",
+		"sample/README.md": "# sample\n",
+		"sample/bin/run":   "#!/bin/sh\n",
+	})
+
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: TestAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+					resource "utilities_extract_tar_gz" "example" {
+						destination = "%s"
+						source      = "%s"
+						file_mode   = "0640"
+						dir_mode    = "0750"
+					}
+					`, extractedDir, tarGzFilePath),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("utilities_extract_tar_gz.example", "file_mode", "0640"),
+					resource.TestCheckResourceAttr("utilities_extract_tar_gz.example", "dir_mode", "0750"),
+					func(s *terraform.State) error {
+						info, err := os.Stat(filepath.Join(extractedDir, "sample", "README.md"))
+						if err != nil {
+							return fmt.Errorf("failed to stat extracted file: %v", err)
+						}
+						if info.Mode().Perm() != 0640 {
+							return fmt.Errorf("expected file permissions '0640', got: %04o", info.Mode().Perm())
+						}
+
+						info, err = os.Stat(filepath.Join(extractedDir, "sample"))
+						if err != nil {
+							return fmt.Errorf("failed to stat extracted directory: %v", err)
+						}
+						if info.Mode().Perm() != 0750 {
+							return fmt.Errorf("expected directory permissions '0750', got: %04o", info.Mode().Perm())
+						}
+
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+// writeTestTarGz writes a TarGz archive containing the given entries. Names ending in "/" are directories.
+func writeTestTarGz(t *testing.T, path string, entries map[string]string) {
+	t.Helper()
+
+	file, err := os.Create(path)
+	requireNoError(t, err)
+	defer func() { _ = file.Close() }()
+
+	gzipWriter := gzip.NewWriter(file)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(entries[name])), Typeflag: tar.TypeReg}
+		if strings.HasSuffix(name, "/") {
+			header = &tar.Header{Name: name, Mode: 0755, Typeflag: tar.TypeDir}
+		}
+		requireNoError(t, tarWriter.WriteHeader(header))
+		_, err := tarWriter.Write([]byte(entries[name]))
+		requireNoError(t, err)
+	}
+
+	requireNoError(t, tarWriter.Close())
+	requireNoError(t, gzipWriter.Close())
+}
+
+func TestResourceUtilitiesExtractTarGzSignature(t *testing.T) {
+	tempDir := t.TempDir()
+	tarGzFilePath := filepath.Join(tempDir, "sample.tar.gz")
+	extractedDir := filepath.Join(tempDir, "extracted")
+
+	writeTestTarGz(t, tarGzFilePath, map[string]string{
+		"sample/README.md": "# sample\n",
+	})
+	archive, err := os.ReadFile(tarGzFilePath)
+	requireNoError(t, err)
+
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	requireNoError(t, err)
+	keyID := []byte("testkey1")
+	signature := ed25519.Sign(privateKey, archive)
+	trustedComment := "file:sample.tar.gz"
+	globalSignature := ed25519.Sign(privateKey, append(append([]byte{}, signature...), trustedComment...))
+
+	minisignPublicKey := base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), keyID...), publicKey...))
+	minisignSignature := fmt.Sprintf("untrusted comment: signature\n%s\ntrusted comment: %s\n%s\n",
+		base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), keyID...), signature...)),
+		trustedComment,
+		base64.StdEncoding.EncodeToString(globalSignature),
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sample.tar.gz":
+			_, _ = w.Write(archive)
+		case "/sample.tar.gz.minisig":
+			_, _ = w.Write([]byte(minisignSignature))
+		case "/tampered.tar.gz.minisig":
+			_, _ = w.Write([]byte(strings.Replace(minisignSignature, trustedComment, "file:tampered.tar.gz", 1)))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: TestAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+					resource "utilities_extract_tar_gz" "example" {
+						destination   = "%s"
+						url           = "%s/sample.tar.gz"
+						signature_url = "%s/tampered.tar.gz.minisig"
+						public_key    = "%s"
+					}
+					`, extractedDir, server.URL, server.URL, minisignPublicKey),
+				ExpectError: regexp.MustCompile("Signature Verification Failed"),
+			},
+			{
+				Config: fmt.Sprintf(`
+					resource "utilities_extract_tar_gz" "example" {
+						destination   = "%s"
+						url           = "%s/sample.tar.gz"
+						signature_url = "%s/sample.tar.gz.minisig"
+						public_key    = "%s"
+					}
+					`, extractedDir, server.URL, server.URL, minisignPublicKey),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("utilities_extract_tar_gz.example", "created_files.#", "1"),
+				),
+			},
+		},
+	})
+}
+
+func TestResourceUtilitiesExtractTarGzOpenPGPSignature(t *testing.T) {
+	tempDir := t.TempDir()
+	tarGzFilePath := filepath.Join(tempDir, "sample.tar.gz")
+	extractedDir := filepath.Join(tempDir, "extracted")
+
+	writeTestTarGz(t, tarGzFilePath, map[string]string{
+		"sample/README.md": "# sample\n",
+	})
+	archive, err := os.ReadFile(tarGzFilePath)
+	requireNoError(t, err)
+
+	signer, signerPublicKey := generateTestOpenPGPKey(t, "signer")
+	_, otherPublicKey := generateTestOpenPGPKey(t, "other")
+
+	var signature bytes.Buffer
+	requireNoError(t, openpgp.ArmoredDetachSign(&signature, signer, bytes.NewReader(archive), nil))
+	var badSignature bytes.Buffer
+	requireNoError(t, openpgp.ArmoredDetachSign(&badSignature, signer, strings.NewReader("another archive"), nil))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sample.tar.gz":
+			_, _ = w.Write(archive)
+		case "/sample.tar.gz.asc":
+			_, _ = w.Write(signature.Bytes())
+		case "/bad.tar.gz.asc":
+			_, _ = w.Write(badSignature.Bytes())
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	config := func(signatureFile, publicKey string) string {
+		return fmt.Sprintf(`
+			resource "utilities_extract_tar_gz" "example" {
+				destination   = "%s"
+				url           = "%s/sample.tar.gz"
+				signature_url = "%s/%s"
+				public_key    = <<-EOT
+%s
+				EOT
+			}
+			`, extractedDir, server.URL, server.URL, signatureFile, publicKey)
+	}
+
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: TestAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				// A signature over other content is rejected
+				Config:      config("bad.tar.gz.asc", signerPublicKey),
+				ExpectError: regexp.MustCompile("Signature Verification Failed"),
+			},
+			{
+				// A valid signature made with a key other than the configured one is rejected
+				Config:      config("sample.tar.gz.asc", otherPublicKey),
+				ExpectError: regexp.MustCompile("Signature Verification Failed"),
+			},
+			{
+				Config: config("sample.tar.gz.asc", signerPublicKey),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("utilities_extract_tar_gz.example", "created_files.#", "1"),
+				),
+			},
+		},
+	})
+}
+
+// generateTestOpenPGPKey creates an OpenPGP key pair and returns the signing entity and its
+// armored public key.
+func generateTestOpenPGPKey(t *testing.T, name string) (*openpgp.Entity, string) {
+	entity, err := openpgp.NewEntity(name, "", name+"@example.com", nil)
+	requireNoError(t, err)
+
+	var publicKey bytes.Buffer
+	writer, err := armor.Encode(&publicKey, openpgp.PublicKeyType, nil)
+	requireNoError(t, err)
+	requireNoError(t, entity.Serialize(writer))
+	requireNoError(t, writer.Close())
+
+	return entity, publicKey.String()
+}
+
+func TestResourceUtilitiesExtractTarGzPlannedFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	tarGzFilePath := filepath.Join(tempDir, "sample.tar.gz")
+	extractedDir := filepath.Join(tempDir, "extracted")
+
+	writeTestTarGz(t, tarGzFilePath, map[string]string{
+		"sample/":          "",
+		"sample/README.md": "# sample\n",
+	})
+
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: TestAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+					resource "utilities_extract_tar_gz" "example" {
+						destination = "%s"
+						source      = "%s"
+					}
+					`, extractedDir, tarGzFilePath),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectKnownValue(
+							"utilities_extract_tar_gz.example",
+							tfjsonpath.New("created_files"),
+							knownvalue.ListExact([]knownvalue.Check{
+								knownvalue.StringExact(filepath.Join(extractedDir, "sample")),
+								knownvalue.StringExact(filepath.Join(extractedDir, "sample", "README.md")),
+							}),
+						),
+					},
+				},
+			},
+		},
+	})
+}
+
+func TestResourceUtilitiesExtractTarGzRename(t *testing.T) {
+	tempDir := t.TempDir()
+	tarGzFilePath := filepath.Join(tempDir, "tool.tar.gz")
+	extractedDir := filepath.Join(tempDir, "extracted")
+
+	writeTestTarGz(t, tarGzFilePath, map[string]string{
+		"tool-1.0/README.md":        "# tool\n",
+		"tool-1.0/bin/tool":         "#!/bin/sh\n",
+		"tool-1.0/share/man/tool.1": ".TH TOOL 1\n",
+	})
+
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: TestAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+					resource "utilities_extract_tar_gz" "example" {
+						destination = "%s"
+						source      = "%s"
+
+						rename = [
+							{ pattern = "^tool-[^/]+/" },
+							{ pattern = "^README\\.md$", drop = true },
+							{ pattern = "^share/man/(.+)$", replacement = "man/man1/$1" },
+						]
+					}
+					`, extractedDir, tarGzFilePath),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectKnownValue(
+							"utilities_extract_tar_gz.example",
+							tfjsonpath.New("created_files"),
+							knownvalue.ListExact([]knownvalue.Check{
+								knownvalue.StringExact(filepath.Join(extractedDir, "bin", "tool")),
+								knownvalue.StringExact(filepath.Join(extractedDir, "man", "man1", "tool.1")),
+							}),
+						),
+					},
+				},
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("utilities_extract_tar_gz.example", "entries_extracted", "2"),
+					resource.TestCheckResourceAttr("utilities_extract_tar_gz.example", "bytes_written", "21"),
+					resource.TestCheckResourceAttr("utilities_extract_tar_gz.example", "skipped_entries", "1"),
+					resource.TestCheckResourceAttrSet("utilities_extract_tar_gz.example", "duration_ms"),
+				),
+			},
+			{
+				Config: fmt.Sprintf(`
+					resource "utilities_extract_tar_gz" "example" {
+						destination = "%s"
+						source      = "%s"
+
+						rename = [
+							{ pattern = "^", replacement = "../" },
+						]
+					}
+					`, extractedDir, tarGzFilePath),
+				ExpectError: regexp.MustCompile(`outside the destination directory`),
+			},
+		},
+	})
+}
+
+func TestResourceUtilitiesExtractTarGzProtectedDestination(t *testing.T) {
+	tempDir := t.TempDir()
+	tarGzFilePath := filepath.Join(tempDir, "sample.tar.gz")
+	protectedDir := filepath.Join(tempDir, "data")
+
+	writeTestTarGz(t, tarGzFilePath, map[string]string{
+		"README.md": "# sample\n",
+	})
+
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: TestAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+					provider "utilities" {
+						protected_paths = ["%s/**"]
+					}
+
+					resource "utilities_extract_tar_gz" "example" {
+						destination = "%s"
+						source      = "%s"
+					}
+					`, protectedDir, filepath.Join(protectedDir, "extracted"), tarGzFilePath),
+				ExpectError: regexp.MustCompile(`is protected`),
+			},
+			{
+				Config: fmt.Sprintf(`
+					provider "utilities" {
+						allowed_roots = ["%s"]
+					}
+
+					resource "utilities_extract_tar_gz" "example" {
+						destination = "%s"
+						source      = "%s"
+					}
+					`, protectedDir, filepath.Join(tempDir, "extracted"), tarGzFilePath),
+				ExpectError: regexp.MustCompile(`outside the allowed roots`),
+			},
+		},
+	})
+}
+
+// writeTestSparseTarGz writes a TarGz archive with a single old GNU sparse entry of the given
+// logical size, holding data at offset and holes everywhere else.
+func writeTestSparseTarGz(t *testing.T, path, name string, size, offset int64, data []byte) {
+	t.Helper()
+
+	header := make([]byte, 512)
+	field := func(start, length int, value string) { copy(header[start:start+length], value) }
+	octal := func(start, length int, value int64) {
+		field(start, length, fmt.Sprintf("%0*o", length-1, value))
+	}
+	field(0, 100, name)
+	octal(100, 8, 0644)
+	octal(108, 8, 0)
+	octal(116, 8, 0)
+	octal(124, 12, int64(len(data)))
+	octal(136, 12, 0)
+	header[156] = tar.TypeGNUSparse
+	field(257, 8, "ustar  \x00")
+	// The sparse map: one data region, then the logical size
+	octal(386, 12, offset)
+	octal(398, 12, int64(len(data)))
+	octal(483, 12, size)
+
+	copy(header[148:156], "        ")
+	var checksum int64
+	for _, b := range header {
+		checksum += int64(b)
+	}
+	field(148, 8, fmt.Sprintf("%06o\x00 ", checksum))
+
+	archive := append(header, data...)
+	archive = append(archive, make([]byte, 512-len(data)%512+1024)...)
+
+	file, err := os.Create(path)
+	requireNoError(t, err)
+	defer func() { _ = file.Close() }()
+
+	gzipWriter := gzip.NewWriter(file)
+	_, err = gzipWriter.Write(archive)
+	requireNoError(t, err)
+	requireNoError(t, gzipWriter.Close())
+}
+
+func TestResourceUtilitiesExtractTarGzSparse(t *testing.T) {
+	tempDir := t.TempDir()
+	tarGzFilePath := filepath.Join(tempDir, "disk.tar.gz")
+	extractedDir := filepath.Join(tempDir, "extracted")
+
+	writeTestSparseTarGz(t, tarGzFilePath, "disk.img", 1<<20, 512000, []byte("DATA"))
+
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: TestAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+					resource "utilities_extract_tar_gz" "example" {
+						destination = "%s"
+						source      = "%s"
+					}
+					`, extractedDir, tarGzFilePath),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("utilities_extract_tar_gz.example", "bytes_written", "1048576"),
+					resource.TestCheckResourceAttr("utilities_extract_tar_gz.example", "physical_bytes", "4096"),
+					func(s *terraform.State) error {
+						content, err := os.ReadFile(filepath.Join(extractedDir, "disk.img"))
+						if err != nil {
+							return fmt.Errorf("failed to read sparse file: %v", err)
+						}
+						if len(content) != 1<<20 {
+							return fmt.Errorf("expected a logical size of %d, got %d", 1<<20, len(content))
+						}
+						if string(content[512000:512004]) != "DATA" {
+							return fmt.Errorf("sparse file data was not restored at its offset")
+						}
+
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
@@ -0,0 +1,82 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build linux
+
+package provider
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+	"golang.org/x/sys/unix"
+)
+
+func TestResourceUtilitiesExtractTarGzPreserveXattrs(t *testing.T) {
+	tempDir := t.TempDir()
+	tarGzFilePath := filepath.Join(tempDir, "xattrs.tar.gz")
+	extractedDir := filepath.Join(tempDir, "extracted")
+
+	file, err := os.Create(tarGzFilePath)
+	requireNoError(t, err)
+	gzipWriter := gzip.NewWriter(file)
+	tarWriter := tar.NewWriter(gzipWriter)
+	requireNoError(t, tarWriter.WriteHeader(&tar.Header{
+		Name:       "bin/tool",
+		Mode:       0755,
+		Size:       int64(len("#!/bin/sh\n")),
+		Typeflag:   tar.TypeReg,
+		Format:     tar.FormatPAX,
+		PAXRecords: map[string]string{"SCHILY.xattr.user.origin": "release"},
+	}))
+	_, err = tarWriter.Write([]byte("#!/bin/sh\n"))
+	requireNoError(t, err)
+	requireNoError(t, tarWriter.Close())
+	requireNoError(t, gzipWriter.Close())
+	requireNoError(t, file.Close())
+
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: TestAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+					resource "utilities_extract_tar_gz" "example" {
+						destination     = "%s"
+						source          = "%s"
+						preserve_xattrs = true
+					}
+					`, extractedDir, tarGzFilePath),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("utilities_extract_tar_gz.example", "preserve_xattrs", "true"),
+					func(s *terraform.State) error {
+						value := make([]byte, 64)
+						n, err := unix.Getxattr(filepath.Join(extractedDir, "bin", "tool"), "user.origin", value)
+						if errors.Is(err, unix.ENOTSUP) {
+							// The filesystem does not support user xattrs; a warning was reported instead
+							return nil
+						}
+						if err != nil {
+							return fmt.Errorf("failed to read extended attribute: %v", err)
+						}
+						if string(value[:n]) != "release" {
+							return fmt.Errorf("expected extended attribute 'release', got '%s'", value[:n])
+						}
+
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
@@ -9,14 +9,12 @@ import (
 	"crypto/sha256"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
 
 	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
-	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
@@ -25,15 +23,100 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
-type resourceUtilitiesExtractZip struct{}
+var (
+	_ resource.Resource               = (*resourceUtilitiesExtractZip)(nil)
+	_ resource.ResourceWithConfigure  = (*resourceUtilitiesExtractZip)(nil)
+	_ resource.ResourceWithModifyPlan = (*resourceUtilitiesExtractZip)(nil)
+)
+
+var zipArchive = archiveKind{Name: "ZIP", Noun: "ZIP file", Extraction: "ZIP extraction"}
+
+type resourceUtilitiesExtractZip struct {
+	policy *pathPolicy
+}
 
 type ExtractZip struct {
-	Source             types.String `tfsdk:"source"`
-	Url                types.String `tfsdk:"url"`
-	Destination        types.String `tfsdk:"destination"`
-	CreatedFiles       types.List   `tfsdk:"created_files"`
-	FileHash           types.String `tfsdk:"file_hash"`
-	DestinationCreated types.Bool   `tfsdk:"destination_created"`
+	Source             types.String    `tfsdk:"source"`
+	Url                types.String    `tfsdk:"url"`
+	Destination        types.String    `tfsdk:"destination"`
+	CreatedFiles       types.List      `tfsdk:"created_files"`
+	FileHash           types.String    `tfsdk:"file_hash"`
+	DestinationCreated types.Bool      `tfsdk:"destination_created"`
+	Owner              types.String    `tfsdk:"owner"`
+	Group              types.String    `tfsdk:"group"`
+	FileMode           types.String    `tfsdk:"file_mode"`
+	DirMode            types.String    `tfsdk:"dir_mode"`
+	Signature          types.String    `tfsdk:"signature"`
+	SignatureURL       types.String    `tfsdk:"signature_url"`
+	PublicKey          types.String    `tfsdk:"public_key"`
+	Password           types.String    `tfsdk:"password"`
+	FilenameEncoding   types.String    `tfsdk:"filename_encoding"`
+	ManifestMode       types.String    `tfsdk:"manifest_mode"`
+	ManifestPath       types.String    `tfsdk:"manifest_path"`
+	ManifestSHA256     types.String    `tfsdk:"manifest_sha256"`
+	FileCount          types.Int64     `tfsdk:"file_count"`
+	CreatedFilesGzip   types.String    `tfsdk:"created_files_compressed"`
+	ExtractNested      *ExtractNested  `tfsdk:"extract_nested"`
+	Rename             []ExtractRename `tfsdk:"rename"`
+	EntriesExtracted   types.Int64     `tfsdk:"entries_extracted"`
+	BytesWritten       types.Int64     `tfsdk:"bytes_written"`
+	PhysicalBytes      types.Int64     `tfsdk:"physical_bytes"`
+	SkippedEntries     types.Int64     `tfsdk:"skipped_entries"`
+	DurationMs         types.Int64     `tfsdk:"duration_ms"`
+}
+
+// options compiles the settings applied to every extracted entry.
+func (d ExtractZip) options() (extractOptions, error) {
+	renamer, err := newEntryRenamer(d.Rename)
+	if err != nil {
+		return extractOptions{}, err
+	}
+
+	return extractOptions{Rename: renamer, Password: d.Password.ValueString(), FilenameEncoding: d.FilenameEncoding.ValueString()}, nil
+}
+
+func (d ExtractZip) manifest() extractManifest {
+	return extractManifest{
+		CreatedFiles: d.CreatedFiles,
+		Compressed:   d.CreatedFilesGzip,
+		Digest:       d.ManifestSHA256,
+		FileCount:    d.FileCount,
+	}
+}
+
+func (d *ExtractZip) setManifest(manifest extractManifest) {
+	d.CreatedFiles = manifest.CreatedFiles
+	d.CreatedFilesGzip = manifest.Compressed
+	d.ManifestSHA256 = manifest.Digest
+	d.FileCount = manifest.FileCount
+}
+
+func (d *ExtractZip) setReport(report *extractReport) {
+	d.EntriesExtracted = types.Int64Value(report.EntriesExtracted)
+	d.BytesWritten = types.Int64Value(report.BytesWritten)
+	d.PhysicalBytes = types.Int64Value(report.PhysicalBytes)
+	d.SkippedEntries = types.Int64Value(report.SkippedEntries)
+	d.DurationMs = types.Int64Value(report.Duration.Milliseconds())
+}
+
+// target returns the shared state that describes the extracted entries.
+func (d ExtractZip) target() extractTarget {
+	return extractTarget{
+		Destination:        d.Destination.ValueString(),
+		DestinationCreated: d.DestinationCreated.ValueBool(),
+		Ownership:          newExtractOwnership(d.Owner, d.Group, d.FileMode, d.DirMode),
+		ManifestMode:       d.ManifestMode.ValueString(),
+		ManifestPath:       d.ManifestPath,
+		Manifest:           d.manifest(),
+	}
+}
+
+// setOwnershipDrift stores the observed value of every ownership setting that drifted.
+func (d *ExtractZip) setOwnershipDrift(drift extractOwnership) {
+	d.Owner = driftedValue(d.Owner, drift.Owner)
+	d.Group = driftedValue(d.Group, drift.Group)
+	d.FileMode = driftedValue(d.FileMode, drift.FileMode)
+	d.DirMode = driftedValue(d.DirMode, drift.DirMode)
 }
 
 // NewResourceUtilitiesExtractZip creates a new instance of the resource.
@@ -41,6 +124,10 @@ func NewResourceUtilitiesExtractZip() resource.Resource {
 	return &resourceUtilitiesExtractZip{}
 }
 
+func (r *resourceUtilitiesExtractZip) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.policy = providerPathPolicy(req.ProviderData)
+}
+
 // Metadata sets the resource type name.
 func (r *resourceUtilitiesExtractZip) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = "utilities_extract_zip"
@@ -68,6 +155,15 @@ func (r *resourceUtilitiesExtractZip) Create(ctx context.Context, req resource.C
 
 	destination := data.Destination.ValueString()
 
+	// Refuse destinations that the provider's path policy does not allow
+	if err := r.policy.checkAllowed(destination); err != nil {
+		resp.Diagnostics.AddError(
+			"Destination Not Allowed",
+			fmt.Sprintf("Refusing to extract to '%s': %v", destination, err),
+		)
+		return
+	}
+
 	// Calculate the hash of the source file if the source is provided
 	var fileHash string
 	var err error
@@ -82,13 +178,25 @@ func (r *resourceUtilitiesExtractZip) Create(ctx context.Context, req resource.C
 		}
 	}
 
+	// Compile the settings applied to every extracted entry
+	options, err := data.options()
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Invalid Rename Rule",
+			err.Error(),
+		)
+		return
+	}
+	ctx, options.Report = newExtractReport(ctx)
+
 	// Extract the ZIP file based on source or URL
 	var createdFiles []string
 	var destinationCreated bool
 	if source != "" {
-		createdFiles, destinationCreated, err = r.validateAndExtractZip(ctx, source, destination, &resp.Diagnostics)
+		createdFiles, destinationCreated, err = r.validateAndExtractZip(ctx, source, destination, options, &resp.Diagnostics)
 	} else if url != "" {
-		createdFiles, destinationCreated, err = r.validateAndExtractZipFromURL(ctx, url, destination, &resp.Diagnostics)
+		signature := newArchiveSignature(data.Signature, data.SignatureURL, data.PublicKey)
+		createdFiles, destinationCreated, err = r.validateAndExtractZipFromURL(ctx, url, destination, signature, options, &resp.Diagnostics)
 	}
 
 	if err != nil {
@@ -99,15 +207,12 @@ func (r *resourceUtilitiesExtractZip) Create(ctx context.Context, req resource.C
 		return
 	}
 
-	// Convert created files to Terraform state format
-	var createdFileList []types.String
-	for _, file := range createdFiles {
-		createdFileList = append(createdFileList, types.StringValue(file))
-	}
-
-	var attrList []attr.Value
-	for _, createdFile := range createdFileList {
-		attrList = append(attrList, createdFile)
+	// Apply ownership, extended attributes and the manifest to the extracted entries
+	target := data.target()
+	target.DestinationCreated = destinationCreated
+	manifest, ok := finishExtraction(ctx, target, createdFiles, data.ExtractNested, options, &resp.Diagnostics)
+	if !ok {
+		return
 	}
 
 	// Update state with hash and created files
@@ -115,12 +220,26 @@ func (r *resourceUtilitiesExtractZip) Create(ctx context.Context, req resource.C
 		Source:             data.Source,
 		Url:                data.Url,
 		Destination:        data.Destination,
-		CreatedFiles:       types.ListValueMust(types.StringType, attrList),
 		FileHash:           types.StringValue(fileHash),
 		DestinationCreated: types.BoolValue(destinationCreated),
-	}
-
-	resp.State.Set(ctx, &stateData)
+		Owner:              data.Owner,
+		Group:              data.Group,
+		FileMode:           data.FileMode,
+		DirMode:            data.DirMode,
+		Signature:          data.Signature,
+		SignatureURL:       data.SignatureURL,
+		PublicKey:          data.PublicKey,
+		Password:           data.Password,
+		FilenameEncoding:   data.FilenameEncoding,
+		ManifestMode:       data.ManifestMode,
+		ManifestPath:       data.ManifestPath,
+		ExtractNested:      data.ExtractNested,
+		Rename:             data.Rename,
+	}
+	stateData.setManifest(manifest)
+	stateData.setReport(options.Report)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &stateData)...)
 }
 
 func (r *resourceUtilitiesExtractZip) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
@@ -132,28 +251,78 @@ func (r *resourceUtilitiesExtractZip) Delete(ctx context.Context, req resource.D
 		return
 	}
 
-	// Convert CreatedFiles from basetypes.ListValue to a Go slice
-	var createdFiles []string
-	resp.Diagnostics.Append(data.CreatedFiles.ElementsAs(ctx, &createdFiles, false)...)
-	if resp.Diagnostics.HasError() {
+	// Delete the extracted files, children before their parents, and the sidecar manifest
+	deleteExtractTarget(ctx, data.target(), &resp.Diagnostics)
+
+	// Clear the state to remove the resource from Terraform's state
+	resp.State.RemoveResource(ctx)
+}
+
+// ModifyPlan reads the listing of a local source archive so that created_files and the manifest
+// digest are known at plan time.
+func (r *resourceUtilitiesExtractZip) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to plan when the resource is being destroyed
+	if req.Plan.Raw.IsNull() {
 		return
 	}
 
-	// Delete all created files in reverse order to ensure directories are deleted last
-	for i := len(createdFiles) - 1; i >= 0; i-- {
-		file := createdFiles[i]
+	var data ExtractZip
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-		// Check if file exists and attempt to delete
-		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
-			resp.Diagnostics.AddWarning(
-				"File Deletion Failed",
-				fmt.Sprintf("Could not delete file '%s': %v", file, err),
-			)
+	// A manifest that drifted during refresh forces the files to be extracted again
+	if !req.State.Raw.IsNull() {
+		var state ExtractZip
+		resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		if state.ManifestSHA256.ValueString() == "" && !state.ManifestSHA256.IsNull() {
+			data.ManifestSHA256 = types.StringUnknown()
+			resp.Diagnostics.Append(resp.Plan.Set(ctx, &data)...)
 		}
 	}
 
-	// Clear the state to remove the resource from Terraform's state
-	resp.State.RemoveResource(ctx)
+	// Invalid rename patterns are reported at plan time
+	options, err := data.options()
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Invalid Rename Rule",
+			err.Error(),
+		)
+		return
+	}
+
+	// The listing can only be read for local archives known at plan time, and does not
+	// include the contents of nested archives
+	if data.Source.IsNull() || data.Source.IsUnknown() || data.Destination.IsUnknown() || data.ExtractNested != nil {
+		return
+	}
+
+	source := data.Source.ValueString()
+	if _, err := os.Stat(source); err != nil {
+		// The archive may be created by another resource during apply
+		return
+	}
+
+	createdFiles, err := listZipFile(source, data.Destination.ValueString(), options)
+	if err != nil {
+		resp.Diagnostics.AddWarning(
+			"ZIP Listing Failed",
+			fmt.Sprintf("Unable to read the contents of '%s' during plan, created_files will be known after apply: %v", source, err),
+		)
+		return
+	}
+
+	manifest, err := newExtractManifest(data.ManifestMode.ValueString(), createdFiles)
+	if err != nil {
+		return
+	}
+
+	data.setManifest(manifest)
+	resp.Diagnostics.Append(resp.Plan.Set(ctx, &data)...)
 }
 
 func (r *resourceUtilitiesExtractZip) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
@@ -165,38 +334,25 @@ func (r *resourceUtilitiesExtractZip) Read(ctx context.Context, req resource.Rea
 		return
 	}
 
-	// Skip file hash validation if URL is set
-	hash := ""
-
-	if data.Url.IsNull() {
-		// Check for hash validation if FileHash is set and URL is not provided
-		if !data.FileHash.IsNull() {
-			var err error
-			hash, err = calculateFileHash(data.Source.ValueString())
-			if err != nil {
-				resp.Diagnostics.AddError(
-					"File Hash Calculation Failed",
-					fmt.Sprintf("Unable to calculate hash for source file: %s", err),
-				)
-				return
-			}
-
-			// Detect drift: if hash mismatch, update the FileHash field
-			if hash != data.FileHash.ValueString() {
-				data.FileHash = types.StringValue(hash)
-				resp.Diagnostics.AddWarning(
-					"File Hash Mismatch Detected",
-					"The hash of the ZIP file has changed, marking the resource for update.",
-				)
-			}
-		}
+	// Detect drift of a local source
+	data.FileHash = sourceHashDrift(data.Source, data.Url, data.FileHash, "ZIP file", &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
+	// Detect drift in the manifest and in the ownership and permissions of the extracted entries
+	manifest, drift := extractTargetDrift(ctx, data.target(), &resp.Diagnostics)
+	data.ManifestSHA256 = manifest.Digest
+	data.FileCount = manifest.FileCount
+	data.setOwnershipDrift(drift)
+
 	// Update the state at the end
-	resp.State.Set(ctx, &data)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
 func (r *resourceUtilitiesExtractZip) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	permissionsRegex := regexp.MustCompile(`^0[0-7]{3}$`)
+
 	resp.Schema = schema.Schema{
 		MarkdownDescription: "Extracts a ZIP archive to a specified directory.",
 		Attributes: map[string]schema.Attribute{
@@ -222,7 +378,50 @@ func (r *resourceUtilitiesExtractZip) Schema(ctx context.Context, req resource.S
 			"created_files": schema.ListAttribute{
 				ElementType: types.StringType,
 				Computed:    true,
-				Description: "A list of paths to the files created during ZIP extraction.",
+				Description: "A list of paths to the files created during ZIP extraction. Known at plan time when `source` is a local archive. Empty unless `manifest_mode` is `state`.",
+			},
+			"created_files_compressed": schema.StringAttribute{
+				Computed:    true,
+				Description: "The list of created files, gzip-compressed and base64-encoded, when `manifest_mode` is `compressed`.",
+			},
+			"manifest_mode": schema.StringAttribute{
+				Optional:    true,
+				Description: "How the list of created files is recorded: `state` (default) stores it in `created_files`, `sidecar` writes it to `manifest_path` and `compressed` stores it in `created_files_compressed`.",
+				Validators: []validator.String{
+					stringvalidator.OneOf(manifestModeState, manifestModeSidecar, manifestModeCompressed),
+				},
+			},
+			"manifest_path": schema.StringAttribute{
+				Optional:    true,
+				Description: "The sidecar manifest file used when `manifest_mode` is `sidecar`. Defaults to `.utilities-manifest` inside the destination.",
+			},
+			"manifest_sha256": schema.StringAttribute{
+				Computed:    true,
+				Description: "The SHA-256 digest of the list of created files.",
+			},
+			"file_count": schema.Int64Attribute{
+				Computed:    true,
+				Description: "The number of files created during ZIP extraction.",
+			},
+			"entries_extracted": schema.Int64Attribute{
+				Computed:    true,
+				Description: "The number of entries written by the last extraction, including nested archives.",
+			},
+			"bytes_written": schema.Int64Attribute{
+				Computed:    true,
+				Description: "The logical size of the files written by the last extraction, counting the holes of sparse files.",
+			},
+			"physical_bytes": schema.Int64Attribute{
+				Computed:    true,
+				Description: "The number of bytes physically written by the last extraction. Holes in sparse files are not written.",
+			},
+			"skipped_entries": schema.Int64Attribute{
+				Computed:    true,
+				Description: "The number of entries skipped by the last extraction, such as entries dropped by a rename rule.",
+			},
+			"duration_ms": schema.Int64Attribute{
+				Computed:    true,
+				Description: "The time taken by the last extraction, in milliseconds.",
 			},
 			"file_hash": schema.StringAttribute{
 				Computed:    true,
@@ -232,6 +431,72 @@ func (r *resourceUtilitiesExtractZip) Schema(ctx context.Context, req resource.S
 				Computed:    true,
 				Description: "Indicates whether the destination directory was created by the resource.",
 			},
+			"owner": schema.StringAttribute{
+				Optional:    true,
+				Description: "User to own every extracted file and directory.",
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"group": schema.StringAttribute{
+				Optional:    true,
+				Description: "Group to own every extracted file and directory.",
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"file_mode": schema.StringAttribute{
+				Optional:    true,
+				Description: "Permissions to set on every extracted file, in octal format (e.g., 0644).",
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(permissionsRegex, "must be a valid octal permission (e.g., 0644)"),
+				},
+			},
+			"dir_mode": schema.StringAttribute{
+				Optional:    true,
+				Description: "Permissions to set on every extracted directory, in octal format (e.g., 0755).",
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(permissionsRegex, "must be a valid octal permission (e.g., 0755)"),
+				},
+			},
+			"signature": schema.StringAttribute{
+				Optional:    true,
+				Description: "A detached OpenPGP or minisign signature of the ZIP file downloaded from `url`.",
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(path.MatchRelative().AtParent().AtName("signature_url")),
+					stringvalidator.AlsoRequires(path.MatchRelative().AtParent().AtName("url"), path.MatchRelative().AtParent().AtName("public_key")),
+				},
+			},
+			"signature_url": schema.StringAttribute{
+				Optional:    true,
+				Description: "The URL of a detached OpenPGP or minisign signature of the ZIP file downloaded from `url`.",
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(path.MatchRelative().AtParent().AtName("signature")),
+					stringvalidator.AlsoRequires(path.MatchRelative().AtParent().AtName("url"), path.MatchRelative().AtParent().AtName("public_key")),
+					stringvalidator.RegexMatches(regexp.MustCompile(`^https?://.+`), "must be a valid HTTP(S) URL"),
+				},
+			},
+			"extract_nested": extractNestedSchema(),
+			"rename":         renameSchema(),
+			"filename_encoding": schema.StringAttribute{
+				Optional:    true,
+				Description: "The encoding of entry names that are not flagged as UTF-8: `auto` (default), `utf-8`, `cp437`, `shift_jis`, `gbk`, `big5` or `euc_kr`. In `auto` mode the Info-ZIP Unicode Path field is used when present, names that are valid UTF-8 are kept and other names are read as CP437.",
+				Validators: []validator.String{
+					stringvalidator.OneOf(filenameEncodingAuto, filenameEncodingUTF8, filenameEncodingCP437, filenameEncodingShiftJIS, filenameEncodingGBK, filenameEncodingBig5, filenameEncodingEUCKR),
+				},
+			},
+			"password": schema.StringAttribute{
+				Optional:    true,
+				Sensitive:   true,
+				Description: "The password used to decrypt encrypted entries. Traditional ZipCrypto and WinZip AES-128/192/256 entries are supported. Unencrypted entries are extracted as usual.",
+			},
+			"public_key": schema.StringAttribute{
+				Optional:    true,
+				Description: "The armored OpenPGP public key or minisign public key used to verify the signature. Extraction is blocked if verification fails.",
+				Validators: []validator.String{
+					stringvalidator.AlsoRequires(path.MatchRelative().AtParent().AtName("url")),
+				},
+			},
 		},
 	}
 }
@@ -248,11 +513,21 @@ func (r *resourceUtilitiesExtractZip) Update(ctx context.Context, req resource.U
 	}
 
 	source := planData.Source.ValueString()
+	url := planData.Url.ValueString()
 	destination := planData.Destination.ValueString()
 
+	// Refuse destinations that the provider's path policy does not allow
+	if err := r.policy.checkAllowed(destination); err != nil {
+		resp.Diagnostics.AddError(
+			"Destination Not Allowed",
+			fmt.Sprintf("Refusing to extract to '%s': %v", destination, err),
+		)
+		return
+	}
+
 	// If the source is a URL, skip file hash calculation
 	newFileHash := ""
-	if !planData.Url.IsNull() {
+	if planData.Url.IsNull() {
 		// Calculate the new hash of the source file
 		var err error
 		newFileHash, err = calculateFileHash(source)
@@ -266,14 +541,66 @@ func (r *resourceUtilitiesExtractZip) Update(ctx context.Context, req resource.U
 	}
 
 	// Compare new hash with the existing state hash if hash is being used (not a URL)
-	if stateData.FileHash.ValueString() == newFileHash {
-		// No change, update state without re-extraction
-		resp.State.Set(ctx, &stateData)
+	unchanged := stateData.FileHash.ValueString() == newFileHash && stateData.Url.Equal(planData.Url) && stateData.Destination.Equal(planData.Destination) && stateData.ExtractNested.equal(planData.ExtractNested) && renameRulesEqual(stateData.Rename, planData.Rename) && stateData.FilenameEncoding.Equal(planData.FilenameEncoding)
+
+	// A planned manifest that differs from the state means the archive contents changed
+	if !planData.ManifestSHA256.IsUnknown() && !planData.ManifestSHA256.Equal(stateData.ManifestSHA256) {
+		unchanged = false
+	}
+
+	var createdFiles []string
+	if unchanged {
+		var err error
+		createdFiles, err = readExtractManifest(ctx, stateData.ManifestMode.ValueString(), manifestPathFor(stateData.Destination.ValueString(), stateData.ManifestPath), stateData.manifest())
+		if err != nil {
+			unchanged = false
+		}
+	}
+
+	if unchanged {
+		// No change, re-apply ownership without re-extraction
+		target := planData.target()
+		target.DestinationCreated = stateData.DestinationCreated.ValueBool()
+		manifest, ok := applyExtractTarget(ctx, target, createdFiles, nil, &resp.Diagnostics)
+		if !ok {
+			return
+		}
+
+		stateData.Source = planData.Source
+		stateData.Owner = planData.Owner
+		stateData.Group = planData.Group
+		stateData.FileMode = planData.FileMode
+		stateData.DirMode = planData.DirMode
+		stateData.Signature = planData.Signature
+		stateData.SignatureURL = planData.SignatureURL
+		stateData.PublicKey = planData.PublicKey
+		stateData.Password = planData.Password
+
+		stateData.ManifestMode = planData.ManifestMode
+		stateData.ManifestPath = planData.ManifestPath
+		stateData.setManifest(manifest)
+
+		resp.Diagnostics.Append(resp.State.Set(ctx, &stateData)...)
 		return
 	}
 
 	// Extract the ZIP file since the hash has changed
-	createdFiles, destinationCreated, err := r.validateAndExtractZip(ctx, source, destination, &resp.Diagnostics)
+	var destinationCreated bool
+	options, err := planData.options()
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Invalid Rename Rule",
+			err.Error(),
+		)
+		return
+	}
+	ctx, options.Report = newExtractReport(ctx)
+	if url != "" {
+		signature := newArchiveSignature(planData.Signature, planData.SignatureURL, planData.PublicKey)
+		createdFiles, destinationCreated, err = r.validateAndExtractZipFromURL(ctx, url, destination, signature, options, &resp.Diagnostics)
+	} else {
+		createdFiles, destinationCreated, err = r.validateAndExtractZip(ctx, source, destination, options, &resp.Diagnostics)
+	}
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"ZIP Extraction Failed",
@@ -282,15 +609,12 @@ func (r *resourceUtilitiesExtractZip) Update(ctx context.Context, req resource.U
 		return
 	}
 
-	// Convert created files to Terraform state format
-	var createdFileList []types.String
-	for _, file := range createdFiles {
-		createdFileList = append(createdFileList, types.StringValue(file))
-	}
-
-	var attrList []attr.Value
-	for _, createdFile := range createdFileList {
-		attrList = append(attrList, createdFile)
+	// Apply ownership, extended attributes and the manifest to the extracted entries
+	target := planData.target()
+	target.DestinationCreated = destinationCreated
+	manifest, ok := finishExtraction(ctx, target, createdFiles, planData.ExtractNested, options, &resp.Diagnostics)
+	if !ok {
+		return
 	}
 
 	// Update state with new hash and created files
@@ -298,75 +622,36 @@ func (r *resourceUtilitiesExtractZip) Update(ctx context.Context, req resource.U
 		Source:             planData.Source,
 		Url:                planData.Url,
 		Destination:        planData.Destination,
-		CreatedFiles:       types.ListValueMust(types.StringType, attrList),
 		FileHash:           types.StringValue(newFileHash),
 		DestinationCreated: types.BoolValue(destinationCreated),
-	}
-
-	resp.State.Set(ctx, &updatedStateData)
+		Owner:              planData.Owner,
+		Group:              planData.Group,
+		FileMode:           planData.FileMode,
+		DirMode:            planData.DirMode,
+		Signature:          planData.Signature,
+		SignatureURL:       planData.SignatureURL,
+		PublicKey:          planData.PublicKey,
+		Password:           planData.Password,
+		FilenameEncoding:   planData.FilenameEncoding,
+		ManifestMode:       planData.ManifestMode,
+		ManifestPath:       planData.ManifestPath,
+		ExtractNested:      planData.ExtractNested,
+		Rename:             planData.Rename,
+	}
+	updatedStateData.setManifest(manifest)
+	updatedStateData.setReport(options.Report)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &updatedStateData)...)
 }
 
-func (r *resourceUtilitiesExtractZip) validateAndExtractZip(ctx context.Context, source, destination string, diagnostics *diag.Diagnostics) ([]string, bool, error) {
-	createdFiles := []string{}
-	destinationCreated := false
-
-	// Check if the context is already canceled
-	select {
-	case <-ctx.Done():
-		diagnostics.AddError("Operation Canceled", "Context was canceled before validation started.")
-		return nil, false, ctx.Err()
-	default:
-		// Continue execution
-	}
-
-	// Validate the source ZIP file
-	if _, err := os.Stat(source); os.IsNotExist(err) {
-		diagnostics.AddError(
-			"Source File Not Found",
-			fmt.Sprintf("The source file '%s' does not exist.", source),
-		)
-		return nil, false, fmt.Errorf("source file '%s' does not exist", source)
-	}
-
-	// Check if the destination directory exists
-	if _, err := os.Stat(destination); os.IsNotExist(err) {
-		select {
-		case <-ctx.Done():
-			diagnostics.AddError("Operation Canceled", "Context was canceled before creating the destination directory.")
-			return nil, false, ctx.Err()
-		default:
-			// Attempt to create the directory
-			if err := os.MkdirAll(destination, 0755); err != nil {
-				diagnostics.AddError(
-					"Destination Directory Creation Failed",
-					fmt.Sprintf("Failed to create destination directory '%s': %v", destination, err),
-				)
-				return nil, false, fmt.Errorf("failed to create directory '%s': %w", destination, err)
-			}
-			destinationCreated = true // Mark directory as created
-		}
-	}
-
-	// Extract the ZIP file
-	select {
-	case <-ctx.Done():
-		diagnostics.AddError("Operation Canceled", "Context was canceled before ZIP extraction.")
-		return nil, false, ctx.Err()
-	default:
-		if err := extractZipFile(ctx, source, destination, diagnostics, &createdFiles); err != nil {
-			diagnostics.AddError(
-				"ZIP Extraction Failed",
-				fmt.Sprintf("Error extracting ZIP file from '%s' to '%s': %v", source, destination, err),
-			)
-			return nil, false, err
-		}
-	}
-
-	return createdFiles, destinationCreated, nil
+func (r *resourceUtilitiesExtractZip) validateAndExtractZip(ctx context.Context, source, destination string, options extractOptions, diagnostics *diag.Diagnostics) ([]string, bool, error) {
+	return extractArchive(ctx, zipArchive, source, destination, diagnostics, func(createdFiles *[]string) error {
+		return extractZipFile(ctx, source, destination, options, diagnostics, createdFiles)
+	})
 }
 
 // extractZipFile extracts the contents of a ZIP file to a specified destination.
-func extractZipFile(ctx context.Context, source, destination string, diagnostics *diag.Diagnostics, createdFiles *[]string) error {
+func extractZipFile(ctx context.Context, source, destination string, options extractOptions, diagnostics *diag.Diagnostics, createdFiles *[]string) error {
 	// Open the ZIP file
 	r, err := zip.OpenReader(source)
 	if err != nil {
@@ -389,7 +674,7 @@ func extractZipFile(ctx context.Context, source, destination string, diagnostics
 			return ctx.Err()
 		default:
 			// Extract each file
-			if err := extractZipEntry(ctx, file, destination, diagnostics, createdFiles); err != nil {
+			if err := extractZipEntry(ctx, file, destination, options, diagnostics, createdFiles); err != nil {
 				diagnostics.AddError(
 					"File Extraction Failed",
 					fmt.Sprintf("Failed to extract file '%s' from ZIP: %v", file.Name, err),
@@ -401,10 +686,67 @@ func extractZipFile(ctx context.Context, source, destination string, diagnostics
 	return nil
 }
 
+// listZipFile returns the paths that extracting a ZIP file to destination would create,
+// in the same order as extractZipFile records them.
+func listZipFile(source, destination string, options extractOptions) ([]string, error) {
+	r, err := zip.OpenReader(source)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = r.Close() }()
+
+	createdFiles := []string{}
+	for _, file := range r.File {
+		name, err := zipEntryName(file, options.FilenameEncoding)
+		if err != nil {
+			return nil, err
+		}
+		name, ok := options.Rename.apply(name)
+		if !ok {
+			continue
+		}
+
+		destPath, err := entryDestination(destination, name)
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasSuffix(file.Name, "/") && destPath == destination {
+			continue
+		}
+		createdFiles = append(createdFiles, destPath)
+	}
+
+	return createdFiles, nil
+}
+
 // extractZipEntry extracts an individual entry from a ZIP file.
-func extractZipEntry(ctx context.Context, file *zip.File, destination string, diagnostics *diag.Diagnostics, createdFiles *[]string) error {
-	// Get the file's destination path
-	destPath := filepath.Join(destination, file.Name)
+func extractZipEntry(ctx context.Context, file *zip.File, destination string, options extractOptions, diagnostics *diag.Diagnostics, createdFiles *[]string) error {
+	// Decode legacy entry names before they are used to build paths
+	name, err := zipEntryName(file, options.FilenameEncoding)
+	if err != nil {
+		diagnostics.AddError(
+			"Invalid Entry Name",
+			fmt.Sprintf("Failed to decode the name of entry '%s': %v", file.Name, err),
+		)
+		return err
+	}
+
+	// Rewrite the entry name, skipping dropped entries
+	name, ok := options.Rename.apply(name)
+	if !ok {
+		options.Report.entrySkipped(ctx, file.Name, "dropped by rename rule")
+		return nil
+	}
+
+	// Get the file's destination path, rejecting entries outside the destination
+	destPath, err := entryDestination(destination, name)
+	if err != nil {
+		diagnostics.AddError(
+			"Unsafe Archive Entry",
+			fmt.Sprintf("Refusing to extract '%s': %v", file.Name, err),
+		)
+		return err
+	}
 
 	// Check for cancellation or timeout before proceeding (if needed)
 	select {
@@ -413,13 +755,8 @@ func extractZipEntry(ctx context.Context, file *zip.File, destination string, di
 	default:
 	}
 
-	// Debug: print the directory and file path
-	fmt.Printf("Debug: Directory and file path: %s\n", destPath)
-
 	// Check if it's a directory (ZIP entries for directories have a trailing "/")
 	if strings.HasSuffix(file.Name, "/") {
-		// Debug: print directory path
-		fmt.Printf("Debug: Directory path to be created: %s\n", destPath)
 		// Only create the directory if it doesn't exist (don't create files)
 		if err := os.MkdirAll(destPath, 0755); err != nil {
 			diagnostics.AddError(
@@ -431,6 +768,7 @@ func extractZipEntry(ctx context.Context, file *zip.File, destination string, di
 
 		if destPath != destination {
 			*createdFiles = append(*createdFiles, destPath)
+			options.Report.entryExtracted(ctx, destPath, 0, 0)
 		}
 		// No file to extract, just return
 		return nil
@@ -438,7 +776,6 @@ func extractZipEntry(ctx context.Context, file *zip.File, destination string, di
 
 	// Ensure that the directory for this file exists
 	dirPath := filepath.Dir(destPath)
-	fmt.Printf("Debug: Directory for file path to be created: %s\n", dirPath)
 
 	// Create the directory path if it doesn't exist
 	if err := os.MkdirAll(dirPath, 0755); err != nil {
@@ -449,8 +786,17 @@ func extractZipEntry(ctx context.Context, file *zip.File, destination string, di
 		return err
 	}
 
-	// Open the file within the ZIP archive
-	srcFile, err := file.Open()
+	// Refuse to write through a symbolic link left in the destination
+	if err := prepareEntryPath(destination, destPath); err != nil {
+		diagnostics.AddError(
+			"Unsafe Archive Entry",
+			fmt.Sprintf("Refusing to extract '%s': %v", file.Name, err),
+		)
+		return err
+	}
+
+	// Open the file within the ZIP archive, decrypting it if needed
+	srcFile, err := openZipEntry(file, options.Password)
 	if err != nil {
 		diagnostics.AddError(
 			"File Open Failed",
@@ -472,7 +818,7 @@ func extractZipEntry(ctx context.Context, file *zip.File, destination string, di
 	defer func() { _ = destFile.Close() }()
 
 	// Copy the contents
-	_, err = io.Copy(destFile, srcFile)
+	written, err := io.Copy(destFile, srcFile)
 	if err != nil {
 		diagnostics.AddError(
 			"File Copy Failed",
@@ -481,11 +827,9 @@ func extractZipEntry(ctx context.Context, file *zip.File, destination string, di
 		return err
 	}
 
-	// Debug: print the destination file created
-	fmt.Printf("Debug: Created file: %s\n", destPath)
-
 	// Append to the createdFiles list
 	*createdFiles = append(*createdFiles, destPath)
+	options.Report.entryExtracted(ctx, destPath, written, written)
 
 	return nil
 }
@@ -505,107 +849,12 @@ func calculateFileHash(filepath string) (string, error) {
 	return fmt.Sprintf("%x", hash.Sum(nil)), nil
 }
 
-func (r *resourceUtilitiesExtractZip) validateAndExtractZipFromURL(ctx context.Context, url, destination string, diagnostics *diag.Diagnostics) ([]string, bool, error) {
-	createdFiles := []string{}
-	destinationCreated := false
-
-	// Check if the context is already canceled
-	select {
-	case <-ctx.Done():
-		diagnostics.AddError("Operation Canceled", "Context was canceled before validation started.")
-		return nil, false, ctx.Err()
-	default:
-		// Continue execution
-	}
-
-	// Download the ZIP file from the URL
-	tmpFile, err := downloadZipFile(ctx, url, diagnostics)
+func (r *resourceUtilitiesExtractZip) validateAndExtractZipFromURL(ctx context.Context, url, destination string, signature archiveSignature, options extractOptions, diagnostics *diag.Diagnostics) ([]string, bool, error) {
+	tmpFile, err := downloadArchive(ctx, zipArchive, url, "downloaded-zip-*.zip", signature, diagnostics)
 	if err != nil {
-		diagnostics.AddError(
-			"ZIP Download Failed",
-			fmt.Sprintf("Error downloading the ZIP file from '%s': %v", url, err),
-		)
 		return nil, false, err
 	}
-	defer func() { _ = os.Remove(tmpFile) }() // Ensure the temporary file is removed after extraction
-
-	// Check if the destination directory exists
-	if _, err := os.Stat(destination); os.IsNotExist(err) {
-		select {
-		case <-ctx.Done():
-			diagnostics.AddError("Operation Canceled", "Context was canceled before creating the destination directory.")
-			return nil, false, ctx.Err()
-		default:
-			// Attempt to create the directory
-			if err := os.MkdirAll(destination, 0755); err != nil {
-				diagnostics.AddError(
-					"Destination Directory Creation Failed",
-					fmt.Sprintf("Failed to create destination directory '%s': %v", destination, err),
-				)
-				return nil, false, fmt.Errorf("failed to create directory '%s': %w", destination, err)
-			}
-			destinationCreated = true // Mark directory as created
-		}
-	}
-
-	// Extract the ZIP file
-	select {
-	case <-ctx.Done():
-		diagnostics.AddError("Operation Canceled", "Context was canceled before ZIP extraction.")
-		return nil, false, ctx.Err()
-	default:
-		if err := extractZipFile(ctx, tmpFile, destination, diagnostics, &createdFiles); err != nil {
-			diagnostics.AddError(
-				"ZIP Extraction Failed",
-				fmt.Sprintf("Error extracting ZIP file from '%s' to '%s': %v", tmpFile, destination, err),
-			)
-			return nil, false, err
-		}
-	}
-
-	return createdFiles, destinationCreated, nil
-}
-
-// Helper function to download the ZIP file.
-func downloadZipFile(ctx context.Context, url string, diagnostics *diag.Diagnostics) (string, error) {
-	// Create a temporary file to store the ZIP content
-	tmpFile, err := os.CreateTemp("", "downloaded-zip-*.zip")
-	if err != nil {
-		diagnostics.AddError("Temp File Creation Failed", fmt.Sprintf("Error creating temporary file: %v", err))
-		return "", err
-	}
-
-	// Create an HTTP client with timeout from the context
-	client := &http.Client{}
-
-	// Create a request with context
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		diagnostics.AddError("Request Creation Failed", fmt.Sprintf("Error creating request for URL '%s': %v", url, err))
-		return "", err
-	}
-
-	// Download the ZIP file
-	resp, err := client.Do(req)
-	if err != nil {
-		diagnostics.AddError("HTTP Request Failed", fmt.Sprintf("Error making HTTP request to '%s': %v", url, err))
-		return "", err
-	}
-	defer func() { _ = resp.Body.Close() }()
-
-	// Check if the response status is OK
-	if resp.StatusCode != http.StatusOK {
-		diagnostics.AddError("HTTP Request Failed", fmt.Sprintf("Failed to download file, HTTP status: %s", resp.Status))
-		return "", fmt.Errorf("failed to download file, HTTP status: %s", resp.Status)
-	}
-
-	// Copy the content to the temporary file
-	_, err = io.Copy(tmpFile, resp.Body)
-	if err != nil {
-		diagnostics.AddError("File Write Failed", fmt.Sprintf("Error writing the downloaded file to temporary file: %v", err))
-		return "", err
-	}
+	defer func() { _ = os.Remove(tmpFile) }()
 
-	// Return the path to the temporary file
-	return tmpFile.Name(), nil
+	return r.validateAndExtractZip(ctx, tmpFile, destination, options, diagnostics)
 }
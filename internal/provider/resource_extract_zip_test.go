@@ -4,15 +4,25 @@
 package provider
 
 import (
+	"archive/zip"
+	"crypto/aes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/binary"
 	"fmt"
 	"os"
+	"path/filepath"
 	"regexp"
+	"sort"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
 	"github.com/hashicorp/terraform-plugin-testing/terraform"
 	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+	"golang.org/x/crypto/pbkdf2"
 )
 
 func TestResourceUtilitiesExtractZip(t *testing.T) {
@@ -127,3 +137,328 @@ func TestResourceUtilitiesExtractZip(t *testing.T) {
 		},
 	})
 }
+
+func TestResourceUtilitiesExtractZipSidecarManifest(t *testing.T) {
+	tempDir := t.TempDir()
+	zipFilePath := filepath.Join(tempDir, "sample.zip")
+	extractedDir := filepath.Join(tempDir, "extracted")
+	manifestPath := filepath.Join(tempDir, "sample.manifest")
+
+	writeTestZip(t, zipFilePath, map[string]string{
+		"sample/":          "",
+		"sample/README.md": "# sample\n",
+	})
+
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: TestAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+					resource "utilities_extract_zip" "example" {
+						destination   = "%s"
+						source        = "%s"
+						manifest_mode = "sidecar"
+						manifest_path = "%s"
+					}
+					`, extractedDir, zipFilePath, manifestPath),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("utilities_extract_zip.example", "file_count", "2"),
+					resource.TestCheckNoResourceAttr("utilities_extract_zip.example", "created_files.#"),
+					func(s *terraform.State) error {
+						content, err := os.ReadFile(manifestPath)
+						if err != nil {
+							return fmt.Errorf("failed to read manifest: %v", err)
+						}
+						expected := filepath.Join(extractedDir, "sample") + "\n" + filepath.Join(extractedDir, "sample", "README.md") + "\n"
+						if string(content) != expected {
+							return fmt.Errorf("expected manifest '%s', got '%s'", expected, string(content))
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestResourceUtilitiesExtractZipNested(t *testing.T) {
+	tempDir := t.TempDir()
+	innerPath := filepath.Join(tempDir, "inner.tar.gz")
+	zipFilePath := filepath.Join(tempDir, "bundle.zip")
+	extractedDir := filepath.Join(tempDir, "extracted")
+
+	writeTestTarGz(t, innerPath, map[string]string{
+		"bin/tool": "#!/bin/sh\n",
+	})
+	inner, err := os.ReadFile(innerPath)
+	requireNoError(t, err)
+	writeTestZip(t, zipFilePath, map[string]string{
+		"vendor/inner.tar.gz": string(inner),
+	})
+
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: TestAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+					resource "utilities_extract_zip" "example" {
+						destination = "%s"
+						source      = "%s"
+
+						extract_nested = {
+							patterns = ["**/*.tar.gz"]
+						}
+					}
+					`, extractedDir, zipFilePath),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("utilities_extract_zip.example", "created_files.#", "3"),
+					resource.TestCheckTypeSetElemAttr("utilities_extract_zip.example", "created_files.*", filepath.Join(extractedDir, "vendor", "inner.tar.gz")),
+					resource.TestCheckTypeSetElemAttr("utilities_extract_zip.example", "created_files.*", filepath.Join(extractedDir, "vendor", "inner")),
+					resource.TestCheckTypeSetElemAttr("utilities_extract_zip.example", "created_files.*", filepath.Join(extractedDir, "vendor", "inner", "bin", "tool")),
+				),
+			},
+		},
+	})
+}
+
+func TestResourceUtilitiesExtractZipUnsafeEntry(t *testing.T) {
+	tempDir := t.TempDir()
+	zipFilePath := filepath.Join(tempDir, "unsafe.zip")
+	extractedDir := filepath.Join(tempDir, "extracted")
+
+	writeTestZip(t, zipFilePath, map[string]string{
+		"../escaped.txt": "outside\n",
+	})
+
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: TestAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+					resource "utilities_extract_zip" "example" {
+						destination = "%s"
+						source      = "%s"
+					}
+					`, extractedDir, zipFilePath),
+				ExpectError: regexp.MustCompile(`outside the destination directory`),
+			},
+		},
+	})
+}
+
+func TestResourceUtilitiesExtractZipSymlinkedEntry(t *testing.T) {
+	tempDir := t.TempDir()
+	zipFilePath := filepath.Join(tempDir, "symlinked.zip")
+	extractedDir := filepath.Join(tempDir, "extracted")
+	outsideDir := filepath.Join(tempDir, "outside")
+
+	writeTestZip(t, zipFilePath, map[string]string{
+		"conf/passwd": "overwritten\n",
+	})
+
+	// A symbolic link left in the destination must not redirect the entries below it
+	requireNoError(t, os.MkdirAll(extractedDir, 0755))
+	requireNoError(t, os.MkdirAll(outsideDir, 0755))
+	requireNoError(t, os.Symlink(outsideDir, filepath.Join(extractedDir, "conf")))
+
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: TestAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+					resource "utilities_extract_zip" "example" {
+						destination = "%s"
+						source      = "%s"
+					}
+					`, extractedDir, zipFilePath),
+				ExpectError: regexp.MustCompile(`Unsafe Archive Entry`),
+			},
+		},
+	})
+
+	if _, err := os.Stat(filepath.Join(outsideDir, "passwd")); !os.IsNotExist(err) {
+		t.Fatalf("expected no file to be written outside the destination, got %v", err)
+	}
+}
+
+func TestResourceUtilitiesExtractZipPassword(t *testing.T) {
+	tempDir := t.TempDir()
+	zipFilePath := filepath.Join(tempDir, "encrypted.zip")
+	extractedDir := filepath.Join(tempDir, "extracted")
+
+	writeTestAESZip(t, zipFilePath, map[string]string{
+		"secret.txt": "classified\n",
+	}, "correct horse")
+
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: TestAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+					resource "utilities_extract_zip" "example" {
+						destination = "%s"
+						source      = "%s"
+						password    = "battery staple"
+					}
+					`, extractedDir, zipFilePath),
+				ExpectError: regexp.MustCompile(`incorrect password`),
+			},
+			{
+				Config: fmt.Sprintf(`
+					resource "utilities_extract_zip" "example" {
+						destination = "%s"
+						source      = "%s"
+						password    = "correct horse"
+					}
+					`, extractedDir, zipFilePath),
+				Check: func(s *terraform.State) error {
+					content, err := os.ReadFile(filepath.Join(extractedDir, "secret.txt"))
+					if err != nil {
+						return fmt.Errorf("failed to read decrypted file: %v", err)
+					}
+					if string(content) != "classified\n" {
+						return fmt.Errorf("unexpected decrypted content '%s'", content)
+					}
+
+					return nil
+				},
+			},
+		},
+	})
+}
+
+func TestResourceUtilitiesExtractZipFilenameEncoding(t *testing.T) {
+	tempDir := t.TempDir()
+	zipFilePath := filepath.Join(tempDir, "legacy.zip")
+	extractedDir := filepath.Join(tempDir, "extracted")
+
+	// "報告書.txt" encoded as Shift-JIS, without the UTF-8 flag
+	file, err := os.Create(zipFilePath)
+	requireNoError(t, err)
+	zipWriter := zip.NewWriter(file)
+	writer, err := zipWriter.CreateHeader(&zip.FileHeader{
+		Name:     "\x95\xf1\x8d\x90\x8f\x91.txt",
+		Method:   zip.Store,
+		NonUTF8:  true,
+		Modified: time.Now(),
+	})
+	requireNoError(t, err)
+	_, err = writer.Write([]byte("report\n"))
+	requireNoError(t, err)
+	requireNoError(t, zipWriter.Close())
+	requireNoError(t, file.Close())
+
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: TestAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+					resource "utilities_extract_zip" "example" {
+						destination       = "%s"
+						source            = "%s"
+						filename_encoding = "shift_jis"
+					}
+					`, extractedDir, zipFilePath),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("utilities_extract_zip.example", "created_files.0", filepath.Join(extractedDir, "報告書.txt")),
+					func(s *terraform.State) error {
+						if _, err := os.Stat(filepath.Join(extractedDir, "報告書.txt")); err != nil {
+							return fmt.Errorf("expected decoded file name: %v", err)
+						}
+
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+// writeTestZip writes a ZIP archive containing the given entries. Names ending in "/" are directories.
+func writeTestZip(t *testing.T, path string, entries map[string]string) {
+	t.Helper()
+
+	file, err := os.Create(path)
+	requireNoError(t, err)
+	defer func() { _ = file.Close() }()
+
+	zipWriter := zip.NewWriter(file)
+
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		writer, err := zipWriter.Create(name)
+		requireNoError(t, err)
+		_, err = writer.Write([]byte(entries[name]))
+		requireNoError(t, err)
+	}
+
+	requireNoError(t, zipWriter.Close())
+}
+
+// writeTestAESZip writes a zip archive whose stored entries are encrypted with WinZip AES-256 (AE-2).
+func writeTestAESZip(t *testing.T, path string, entries map[string]string, password string) {
+	t.Helper()
+
+	file, err := os.Create(path)
+	requireNoError(t, err)
+	defer func() { _ = file.Close() }()
+
+	zipWriter := zip.NewWriter(file)
+	for name, content := range entries {
+		salt := make([]byte, 16)
+		_, err := rand.Read(salt)
+		requireNoError(t, err)
+		derived := pbkdf2.Key([]byte(password), salt, 1000, 66, sha1.New)
+
+		block, err := aes.NewCipher(derived[:32])
+		requireNoError(t, err)
+		encrypted := []byte(content)
+		var counter, stream [aes.BlockSize]byte
+		for i := range encrypted {
+			if i%aes.BlockSize == 0 {
+				binary.LittleEndian.PutUint64(counter[:], uint64(i/aes.BlockSize+1))
+				block.Encrypt(stream[:], counter[:])
+			}
+			encrypted[i] ^= stream[i%aes.BlockSize]
+		}
+		mac := hmac.New(sha1.New, derived[32:64])
+		mac.Write(encrypted)
+
+		data := append(append(append(salt, derived[64:]...), encrypted...), mac.Sum(nil)[:10]...)
+		writer, err := zipWriter.CreateRaw(&zip.FileHeader{
+			Name:               name,
+			Method:             99,
+			Flags:              0x1,
+			Extra:              []byte{0x01, 0x99, 0x07, 0x00, 0x02, 0x00, 'A', 'E', 0x03, 0x00, 0x00},
+			CompressedSize64:   uint64(len(data)),
+			UncompressedSize64: uint64(len(content)),
+		})
+		requireNoError(t, err)
+		_, err = writer.Write(data)
+		requireNoError(t, err)
+	}
+	requireNoError(t, zipWriter.Close())
+}
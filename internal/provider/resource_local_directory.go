@@ -7,14 +7,16 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
-	"os/user"
 	"regexp"
 	"runtime"
 	"strconv"
-	"strings"
 
+	"terraform-provider-utilities/internal/fsmeta"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/boolvalidator"
 	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
@@ -23,18 +25,50 @@ import (
 )
 
 var (
-	_ resource.Resource = (*resourceUtilitiesLocalDirectory)(nil)
+	_ resource.Resource               = (*resourceUtilitiesLocalDirectory)(nil)
+	_ resource.ResourceWithModifyPlan = (*resourceUtilitiesLocalDirectory)(nil)
 )
 
-type resourceUtilitiesLocalDirectory struct{}
+type resourceUtilitiesLocalDirectory struct {
+	policy *pathPolicy
+}
 
 type LocalDirectory struct {
-	Force       types.Bool   `tfsdk:"force"`
-	Group       types.String `tfsdk:"group"`
-	Managed     types.Bool   `tfsdk:"managed"`
-	Path        types.String `tfsdk:"path"`
-	Permissions types.String `tfsdk:"permissions"`
-	User        types.String `tfsdk:"user"`
+	Force                types.Bool   `tfsdk:"force"`
+	BackupOnDestroy      types.Bool   `tfsdk:"backup_on_destroy"`
+	BackupDir            types.String `tfsdk:"backup_dir"`
+	PreventDestroy       types.Bool   `tfsdk:"prevent_destroy_if_not_empty"`
+	Group                types.String `tfsdk:"group"`
+	Managed              types.Bool   `tfsdk:"managed"`
+	Path                 types.String `tfsdk:"path"`
+	Permissions          types.String `tfsdk:"permissions"`
+	User                 types.String `tfsdk:"user"`
+	Recursive            types.Bool   `tfsdk:"recursive"`
+	FilePermissions      types.String `tfsdk:"file_permissions"`
+	DirectoryPermissions types.String `tfsdk:"directory_permissions"`
+	Exclude              types.List   `tfsdk:"exclude"`
+	NonconformingEntries types.Int64  `tfsdk:"nonconforming_entries"`
+
+	ACL        []LocalDirectoryACLEntry `tfsdk:"acl"`
+	DefaultACL []LocalDirectoryACLEntry `tfsdk:"default_acl"`
+	Xattrs     types.Map                `tfsdk:"xattrs"`
+}
+
+// treeOwnership returns the ownership enforced on the entries below the directory.
+func (d LocalDirectory) treeOwnership() extractOwnership {
+	return newExtractOwnership(d.User, d.Group, d.FilePermissions, d.DirectoryPermissions)
+}
+
+// treeEntries returns the entries below the directory that recursive enforcement applies to.
+func (d LocalDirectory) treeEntries(ctx context.Context) ([]string, error) {
+	var exclude []string
+	if !d.Exclude.IsNull() && !d.Exclude.IsUnknown() {
+		if diags := d.Exclude.ElementsAs(ctx, &exclude, false); diags.HasError() {
+			return nil, fmt.Errorf("failed to read exclude patterns")
+		}
+	}
+
+	return directoryTreeEntries(d.Path.ValueString(), exclude)
 }
 
 var protectedPaths = []string{
@@ -69,61 +103,82 @@ func NewResourceUtilitiesLocalDirectory() resource.Resource {
 	return &resourceUtilitiesLocalDirectory{}
 }
 
-// func getUserID(userName string) (int, error) {
-// 	cmd := exec.Command("id", "-u", userName)
-// 	output, err := cmd.Output()
-// 	if err != nil {
-// 		return -1, fmt.Errorf("failed to execute id command for user '%s': %v", userName, err)
-// 	}
-
-// 	uid, err := strconv.Atoi(strings.TrimSpace(string(output)))
-// 	if err != nil {
-// 		return -1, fmt.Errorf("failed to convert UID to integer: %v", err)
-// 	}
-
-// 	return uid, nil
-// }
-
-// func getGroupID(groupName string) (int, error) {
-// 	cmd := exec.Command("id", "-g", groupName)
-// 	output, err := cmd.Output()
-// 	if err != nil {
-// 		return -1, fmt.Errorf("failed to execute id command for group '%s': %v", groupName, err)
-// 	}
-
-// 	gid, err := strconv.Atoi(strings.TrimSpace(string(output)))
-// 	if err != nil {
-// 		return -1, fmt.Errorf("failed to convert GID to integer: %v", err)
-// 	}
-
-// 	return gid, nil
-// }
-
-func isProtectedPath(path string) bool {
-	normalizedPath := strings.TrimRight(path, "/")
-
-	for _, protected := range protectedPaths {
-		normalizedProtected := strings.TrimRight(protected, "/")
-		if normalizedPath == normalizedProtected {
-			return true
-		}
+// ownerMatchesUID reports whether a configured user name or numeric ID refers to uid.
+func ownerMatchesUID(userName string, uid int) bool {
+	if userName == strconv.Itoa(uid) {
+		return true
+	}
+
+	id, err := fsmeta.LookupUID(userName)
+	return err == nil && id == uid
+}
+
+// groupMatchesGID reports whether a configured group name or numeric ID refers to gid.
+func groupMatchesGID(groupName string, gid int) bool {
+	if groupName == strconv.Itoa(gid) {
+		return true
 	}
 
-	return false
+	id, err := fsmeta.LookupGID(groupName)
+	return err == nil && id == gid
 }
 
-func getCurrentGroupName() (string, error) {
-	cmd := exec.Command("id", "-gn")
-	output, err := cmd.Output()
+// resolveOwnership defaults an empty user and group to the current user and their group, and
+// resolves both to numeric IDs. On Windows, which has no numeric owner, the IDs are -1.
+func resolveOwnership(userName, groupName string, diagnostics *diag.Diagnostics) (string, string, int, int) {
+	// Compute default user if not set
+	if userName == "" {
+		var err error
+
+		userName, err = fsmeta.CurrentUserName()
+		if err != nil {
+			diagnostics.AddError(
+				"Error Retrieving Current User",
+				err.Error(),
+			)
+			return "", "", -1, -1
+		}
+	}
+
+	// Compute default group if not set
+	if groupName == "" {
+		var err error
+
+		groupName, err = fsmeta.CurrentGroupName()
+		if err != nil {
+			diagnostics.AddError(
+				"Error Retrieving Current Group Name",
+				err.Error(),
+			)
+			return "", "", -1, -1
+		}
+	}
+
+	// Convert user name to UID
+	uid, err := fsmeta.LookupUID(userName)
+	if err != nil {
+		diagnostics.AddError("Invalid User", err.Error())
+		return "", "", -1, -1
+	}
+
+	// Convert group name to GID
+	gid, err := fsmeta.LookupGID(groupName)
 	if err != nil {
-		return "", fmt.Errorf("failed to execute 'id -gn' command: %v", err)
+		diagnostics.AddError("Error Looking Up Group", err.Error())
+		return "", "", -1, -1
+	}
+
+	// For Windows, fallback to default values
+	if runtime.GOOS == "windows" {
+		uid = -1 // No valid UID on Windows
+		gid = -1 // No valid GID on Windows
 	}
 
-	groupName := strings.TrimSpace(string(output))
-	return groupName, nil
+	return userName, groupName, uid, gid
 }
 
 func (r *resourceUtilitiesLocalDirectory) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.policy = providerPathPolicy(req.ProviderData)
 }
 
 func (r *resourceUtilitiesLocalDirectory) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -139,8 +194,15 @@ func (r *resourceUtilitiesLocalDirectory) Create(ctx context.Context, req resour
 		return
 	}
 
-	// Check if it's a valid directory
+	// Check if it's a valid directory within the allowed roots
 	directoryPath := data.Path.ValueString()
+	if !r.policy.isAllowed(directoryPath) {
+		resp.Diagnostics.AddError(
+			"Path Not Allowed",
+			fmt.Sprintf("The path '%s' is outside the allowed roots configured on the provider.", directoryPath),
+		)
+		return
+	}
 	info, err := os.Stat(directoryPath)
 	if err == nil {
 		// Path exists, check if it's a directory
@@ -160,83 +222,13 @@ func (r *resourceUtilitiesLocalDirectory) Create(ctx context.Context, req resour
 		return
 	}
 
-	// Compute default user if not set
-	userName := data.User.ValueString()
-	if userName == "" {
-		currentUserInfo, err := user.Current()
-		if err != nil {
-			resp.Diagnostics.AddError(
-				"Error Retrieving Current User",
-				fmt.Sprintf("Failed to retrieve the current user: %v", err),
-			)
-			return
-		}
-		userName = currentUserInfo.Username
-		data.User = types.StringValue(userName)
-	}
-
-	// Compute default group if not set
-	groupName := data.Group.ValueString()
-	if groupName == "" {
-		var err error
-
-		groupName, err = getCurrentGroupName()
-		if err != nil {
-			resp.Diagnostics.AddError(
-				"Error Retrieving Current Group Name",
-				err.Error(),
-			)
-			return
-		}
-
-		data.Group = types.StringValue(groupName)
-	}
-
-	// Convert user name to UID
-	userInfo, err := user.Lookup(userName)
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Invalid User",
-			fmt.Sprintf("Failed to lookup user '%s': %v", userName, err),
-		)
-		return
-	}
-
-	// Convert UID to integer
-	uid, err := strconv.Atoi(userInfo.Uid)
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Invalid User ID",
-			fmt.Sprintf("Failed to convert user ID '%s' to integer: %v", userInfo.Uid, err),
-		)
-		return
-	}
-
-	// Convert group name to GID
-	groupInfo, err := user.LookupGroup(groupName)
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error Looking Up Group",
-			fmt.Sprintf("Failed to lookup group '%s': %v", groupName, err),
-		)
-		return
-	}
-
-	// Convert GID to integer
-	gid, err := strconv.Atoi(groupInfo.Gid)
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Invalid Group ID",
-			fmt.Sprintf("Failed to convert GID '%s' to integer: %v", groupInfo.Gid, err),
-		)
+	// Resolve the owner, defaulting to the current user and group
+	userName, groupName, uid, gid := resolveOwnership(data.User.ValueString(), data.Group.ValueString(), &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
 		return
 	}
-
-	// For Windows, fallback to default values
-	if runtime.GOOS == "windows" {
-		uid = -1 // No valid UID on Windows
-		gid = -1 // No valid GID on Windows
-	}
+	data.User = types.StringValue(userName)
+	data.Group = types.StringValue(groupName)
 
 	// Check if the directory exists otherwise create
 	if _, err := os.Stat(directoryPath); os.IsNotExist(err) {
@@ -259,7 +251,7 @@ func (r *resourceUtilitiesLocalDirectory) Create(ctx context.Context, req resour
 	}
 
 	// Handle file permission and ownership
-	if isProtectedPath(directoryPath) {
+	if r.policy.isProtected(directoryPath) {
 		tflog.Warn(ctx, "Skipping ownership modification for protected OS path", map[string]interface{}{
 			"path":   directoryPath,
 			"reason": "The specified path is considered critical to the operating system and should not have its ownership modified to avoid potential system instability or security risks.",
@@ -286,6 +278,27 @@ func (r *resourceUtilitiesLocalDirectory) Create(ctx context.Context, req resour
 				return
 			}
 		}
+
+		// Apply ownership and permissions to the whole tree
+		if data.Recursive.ValueBool() {
+			entries, err := data.treeEntries(ctx)
+			if err == nil {
+				err = applyExtractOwnership(ctx, entries, data.treeOwnership())
+			}
+			if err != nil {
+				resp.Diagnostics.AddError(
+					"Error Setting Recursive Ownership",
+					fmt.Sprintf("Failed to apply ownership below '%s': %v", directoryPath, err),
+				)
+				return
+			}
+		}
+
+		// Apply ACLs and extended attributes, which may update the permission bits
+		applyDirectoryAttributes(ctx, &data, nil, &resp.Diagnostics)
+		if resp.Diagnostics.HasError() {
+			return
+		}
 	}
 
 	// Retrieve the current directory info
@@ -303,6 +316,7 @@ func (r *resourceUtilitiesLocalDirectory) Create(ctx context.Context, req resour
 
 	// Set data.Permissions to the current permissions
 	data.Permissions = types.StringValue(fmt.Sprintf("0%o", currentPermissions))
+	data.NonconformingEntries = types.Int64Value(0)
 
 	// Set the state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
@@ -318,6 +332,13 @@ func (r *resourceUtilitiesLocalDirectory) Delete(ctx context.Context, req resour
 	}
 
 	directoryPath := data.Path.ValueString()
+	if !r.policy.isAllowed(directoryPath) {
+		resp.Diagnostics.AddError(
+			"Path Not Allowed",
+			fmt.Sprintf("The path '%s' is outside the allowed roots configured on the provider.", directoryPath),
+		)
+		return
+	}
 
 	// Check if directory exists
 	info, err := os.Stat(directoryPath)
@@ -341,11 +362,19 @@ func (r *resourceUtilitiesLocalDirectory) Delete(ctx context.Context, req resour
 	}
 
 	// Check if the path is protected and prevent deletion if true
-	if isProtectedPath(directoryPath) {
+	if r.policy.isProtected(directoryPath) {
 		tflog.Warn(ctx, "Attempted to delete a protected path, skipping deletion", map[string]interface{}{"path": directoryPath})
 		return
 	}
 
+	// Apply the safeguards before the directory and its contents are removed
+	if data.Force.ValueBool() || data.Managed.ValueBool() {
+		r.prepareRemoval(ctx, data, &resp.Diagnostics)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
 	// Decide the action based on force and managed flags
 	if data.Force.ValueBool() {
 		// Force deletion
@@ -372,6 +401,73 @@ func (r *resourceUtilitiesLocalDirectory) Delete(ctx context.Context, req resour
 	tflog.Info(ctx, "Directory successfully deleted", map[string]interface{}{"path": directoryPath})
 }
 
+// prepareRemoval refuses to remove a directory that is not empty when
+// prevent_destroy_if_not_empty is set, and backs it up when backup_on_destroy is set.
+func (r *resourceUtilitiesLocalDirectory) prepareRemoval(ctx context.Context, data LocalDirectory, diagnostics *diag.Diagnostics) {
+	directoryPath := data.Path.ValueString()
+
+	if data.PreventDestroy.ValueBool() {
+		entries, err := os.ReadDir(directoryPath)
+		if err != nil {
+			diagnostics.AddError("Error Accessing Directory", fmt.Sprintf("Failed to read directory '%s': %v", directoryPath, err))
+			return
+		}
+		if len(entries) > 0 {
+			diagnostics.AddError(
+				"Directory Not Empty",
+				fmt.Sprintf("Refusing to delete the directory '%s', which holds %d entries, because prevent_destroy_if_not_empty is set. Empty the directory or unset the attribute to delete it.", directoryPath, len(entries)),
+			)
+			return
+		}
+	}
+
+	if !data.BackupOnDestroy.ValueBool() {
+		return
+	}
+
+	backupDir := data.BackupDir.ValueString()
+	if isSubPath(directoryPath, backupDir) {
+		diagnostics.AddError(
+			"Invalid Backup Directory",
+			fmt.Sprintf("The backup directory '%s' is inside the directory '%s', which is about to be deleted.", backupDir, directoryPath),
+		)
+		return
+	}
+	if err := r.policy.checkAllowed(backupDir); err != nil {
+		diagnostics.AddError(
+			"Backup Directory Not Allowed",
+			fmt.Sprintf("Refusing to write a backup to '%s': %v", backupDir, err),
+		)
+		return
+	}
+
+	backupPath, err := backupDirectory(directoryPath, backupDir)
+	if err != nil {
+		diagnostics.AddError(
+			"Backup Failed",
+			fmt.Sprintf("Failed to back up the directory '%s', it was not deleted: %v", directoryPath, err),
+		)
+		return
+	}
+
+	tflog.Info(ctx, "Backed up directory before deletion", map[string]interface{}{
+		"path":   directoryPath,
+		"backup": backupPath,
+	})
+	// Deleting a symbolic link leaves the directory it points to, so only the link was backed up
+	if info, err := os.Lstat(directoryPath); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		diagnostics.AddWarning(
+			"Directory Backed Up",
+			fmt.Sprintf("The symbolic link '%s' was backed up to '%s' before deletion. The directory it points to is not deleted.", directoryPath, backupPath),
+		)
+		return
+	}
+	diagnostics.AddWarning(
+		"Directory Backed Up",
+		fmt.Sprintf("The directory '%s' was backed up to '%s' before deletion.", directoryPath, backupPath),
+	)
+}
+
 func (r *resourceUtilitiesLocalDirectory) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
 	var data LocalDirectory
 
@@ -415,9 +511,41 @@ func (r *resourceUtilitiesLocalDirectory) Read(ctx context.Context, req resource
 		return
 	}
 
-	// Set default values for user and group to "unknown" for platform agnosticism
-	userName := "unknown"
-	groupName := "unknown"
+	// Count the entries below the directory that no longer match the configured ownership,
+	// before the owner and group are replaced with the values read from the directory itself
+	data.NonconformingEntries = types.Int64Value(0)
+	if data.Recursive.ValueBool() && !r.policy.isProtected(directoryPath) {
+		entries, err := data.treeEntries(ctx)
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Error Reading Directory Tree",
+				err.Error(),
+			)
+			return
+		}
+		data.NonconformingEntries = types.Int64Value(countNonconformingEntries(entries, data.treeOwnership()))
+	}
+
+	// Read the actual owner and group so that manual changes show up as drift. Ownership of
+	// protected paths is never modified, and Windows has no numeric owner, so the state is kept.
+	userName := data.User.ValueString()
+	groupName := data.Group.ValueString()
+	if uid, gid, ok := fsmeta.Ownership(info); ok && !r.policy.isProtected(directoryPath) {
+		if !ownerMatchesUID(userName, uid) {
+			userName = fsmeta.UserName(uid)
+		}
+		if !groupMatchesGID(groupName, gid) {
+			groupName = fsmeta.GroupName(gid)
+		}
+	}
+
+	// Read back the managed ACLs and extended attributes
+	if !r.policy.isProtected(directoryPath) {
+		readDirectoryAttributes(ctx, &data, info.Mode(), &resp.Diagnostics)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
 
 	// Retrieve and set directory permissions in octal format
 	mode := info.Mode().Perm()
@@ -427,16 +555,46 @@ func (r *resourceUtilitiesLocalDirectory) Read(ctx context.Context, req resource
 
 	// Log the read operation
 	tflog.Info(ctx, "Read local directory", map[string]interface{}{
-		"path":        directoryPath,
-		"user":        userName,
-		"group":       groupName,
-		"permissions": data.Permissions.ValueString(),
+		"path":                  directoryPath,
+		"user":                  userName,
+		"group":                 groupName,
+		"permissions":           data.Permissions.ValueString(),
+		"nonconforming_entries": data.NonconformingEntries.ValueInt64(),
 	})
 
 	// Set the state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
+func (r *resourceUtilitiesLocalDirectory) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to do on destroy
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var data LocalDirectory
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The owner, other and mask entries of the access ACL are the permission bits
+	if err := validateACLPermissions(data.ACL, data.Permissions.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("acl"),
+			"Conflicting ACL and Permissions",
+			fmt.Sprintf("The access ACL of '%s' contradicts its permissions: %v", data.Path.ValueString(), err),
+		)
+		return
+	}
+
+	// Applying always leaves the tree conforming, so non-conforming entries found by Read
+	// produce a diff that re-applies ownership
+	data.NonconformingEntries = types.Int64Value(0)
+
+	resp.Diagnostics.Append(resp.Plan.Set(ctx, &data)...)
+}
+
 func (r *resourceUtilitiesLocalDirectory) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
 	permissionsRegex := regexp.MustCompile(`^0[0-7]{3}$`)
 
@@ -446,7 +604,9 @@ The **utilities_local_directory** resource manages a local directory on the file
 
 - **Managed vs Unmanaged**: Directories created by this resource are considered _managed_. Pre-existing directories are automatically marked as _unmanaged_.
 - **Force Deletion**: The **force** attribute can be set to true to remove unmanaged directories during the destroy phase.
-- **Permissions and Ownership**: The resource allows setting file permissions in octal format (e.g., **0755**) and specifying the user and group ownership.
+- **Deletion Safeguards**: **backup_on_destroy** archives the directory to **backup_dir** before it is removed, and **prevent_destroy_if_not_empty** refuses to remove a directory that still holds files.
+- **Permissions and Ownership**: The resource allows setting file permissions in octal format (e.g., **0755**) and specifying the user and group ownership. Ownership or permissions changed outside of Terraform are reported as drift.
+- **ACLs and Extended Attributes**: POSIX access and default ACLs and extended attributes can be managed on Linux. They are skipped with a warning on filesystems that do not support them.
 
 **Note**: This resource is currently **not supported** on Windows systems.
 `,
@@ -483,25 +643,82 @@ The **utilities_local_directory** resource manages a local directory on the file
 				Optional:            true,
 				MarkdownDescription: "Whether to force creation of the directory, even if it already exists. Default is false.",
 			},
+			"backup_on_destroy": schema.BoolAttribute{
+				Optional:            true,
+				MarkdownDescription: "Whether to write a timestamped **tar.gz** archive of the directory to **backup_dir** before it is deleted. The directory is not deleted if the backup fails. When **path** is a symbolic link, only the link is deleted and backed up. Default is false.",
+				Validators: []validator.Bool{
+					boolvalidator.AlsoRequires(path.MatchRoot("backup_dir")),
+				},
+			},
+			"backup_dir": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Directory the backups are written to, as **<name>-<UTC timestamp>.tar.gz**. Missing directories are created. Must not be inside **path**.",
+			},
+			"prevent_destroy_if_not_empty": schema.BoolAttribute{
+				Optional:            true,
+				MarkdownDescription: "Whether to fail the deletion of the directory when it is not empty, instead of removing its contents. Default is false.",
+			},
 			"managed": schema.BoolAttribute{
 				Computed:            true,
 				MarkdownDescription: "Indicates whether the directory is managed by this provider. Defaults to false for existing directories.",
 			},
+			"recursive": schema.BoolAttribute{
+				Optional:            true,
+				MarkdownDescription: "Whether to apply **user**, **group**, **file_permissions** and **directory_permissions** to every entry below the directory. Default is false.",
+			},
+			"file_permissions": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Permissions to set on every file below the directory when **recursive** is true, in octal format (e.g., 0644).",
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(permissionsRegex, "must be a valid octal permission (e.g., 0644)"),
+				},
+			},
+			"directory_permissions": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Permissions to set on every directory below the directory when **recursive** is true, in octal format (e.g., 0755).",
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(permissionsRegex, "must be a valid octal permission (e.g., 0755)"),
+				},
+			},
+			"exclude": schema.ListAttribute{
+				ElementType:         types.StringType,
+				Optional:            true,
+				MarkdownDescription: "Glob patterns, relative to **path**, of entries that recursive enforcement skips. A `**` segment matches any number of directories, and excluded directories are skipped entirely.",
+			},
+			"acl":         aclSchema("POSIX access ACL of the directory. The owner, owning group and other entries default to the directory permissions, and when named entries are present the mask defaults to the group bits of **permissions**. Without **permissions**, the mask defaults to the union of the group class permissions, which then becomes the group bits of the directory. Owner, other and mask entries must match **permissions**. Removing the attribute removes the named entries, leaving the access ACL described by the directory permissions alone."),
+			"default_acl": aclSchema("POSIX default ACL of the directory, inherited by the files and directories created in it. Missing owner, owning group, other and mask entries are completed as for **acl**. Removing the attribute removes the default ACL from the directory."),
+			"xattrs": schema.MapAttribute{
+				ElementType:         types.StringType,
+				Optional:            true,
+				MarkdownDescription: "Extended attributes to set on the directory, keyed by name including the namespace (e.g., `user.project`). Attributes removed from the map are removed from the directory, other attributes of the directory are left alone.",
+			},
+			"nonconforming_entries": schema.Int64Attribute{
+				Computed:            true,
+				MarkdownDescription: "The number of entries below the directory whose ownership or permissions do not match the configuration. A non-zero value read during refresh plans an update that enforces them again.",
+			},
 		},
 	}
 }
 
 func (r *resourceUtilitiesLocalDirectory) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
-	var data LocalDirectory
+	var data, state LocalDirectory
 
-	// Retrieve plan data
+	// Retrieve plan and prior state data
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	// Check if it's a valid directory
+	// Check if it's a valid directory within the allowed roots
 	directoryPath := data.Path.ValueString()
+	if !r.policy.isAllowed(directoryPath) {
+		resp.Diagnostics.AddError(
+			"Path Not Allowed",
+			fmt.Sprintf("The path '%s' is outside the allowed roots configured on the provider.", directoryPath),
+		)
+		return
+	}
 	info, err := os.Stat(directoryPath)
 	if err == nil {
 		// Path exists, check if it's a directory
@@ -521,83 +738,13 @@ func (r *resourceUtilitiesLocalDirectory) Update(ctx context.Context, req resour
 		return
 	}
 
-	// Compute default user if not set
-	userName := data.User.ValueString()
-	if userName == "" {
-		currentUserInfo, err := user.Current()
-		if err != nil {
-			resp.Diagnostics.AddError(
-				"Error Retrieving Current User",
-				fmt.Sprintf("Failed to retrieve the current user: %v", err),
-			)
-			return
-		}
-		userName = currentUserInfo.Username
-		data.User = types.StringValue(userName)
-	}
-
-	// Compute default group if not set
-	groupName := data.Group.ValueString()
-	if groupName == "" {
-		var err error
-
-		groupName, err = getCurrentGroupName()
-		if err != nil {
-			resp.Diagnostics.AddError(
-				"Error Retrieving Current Group Name",
-				err.Error(),
-			)
-			return
-		}
-
-		data.Group = types.StringValue(groupName)
-	}
-
-	// Convert user name to UID
-	userInfo, err := user.Lookup(userName)
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Invalid User",
-			fmt.Sprintf("Failed to lookup user '%s': %v", userName, err),
-		)
-		return
-	}
-
-	// Convert UID to integer
-	uid, err := strconv.Atoi(userInfo.Uid)
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Invalid User ID",
-			fmt.Sprintf("Failed to convert user ID '%s' to integer: %v", userInfo.Uid, err),
-		)
-		return
-	}
-
-	// Convert group name to GID
-	groupInfo, err := user.LookupGroup(groupName)
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error Looking Up Group",
-			fmt.Sprintf("Failed to lookup group '%s': %v", groupName, err),
-		)
-		return
-	}
-
-	// Convert GID to integer
-	gid, err := strconv.Atoi(groupInfo.Gid)
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Invalid Group ID",
-			fmt.Sprintf("Failed to convert GID '%s' to integer: %v", groupInfo.Gid, err),
-		)
+	// Resolve the owner, defaulting to the current user and group
+	userName, groupName, uid, gid := resolveOwnership(data.User.ValueString(), data.Group.ValueString(), &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
 		return
 	}
-
-	// For Windows, fallback to default values
-	if runtime.GOOS == "windows" {
-		uid = -1 // No valid UID on Windows
-		gid = -1 // No valid GID on Windows
-	}
+	data.User = types.StringValue(userName)
+	data.Group = types.StringValue(groupName)
 
 	// Check if the directory exists otherwise create
 	if _, err := os.Stat(directoryPath); os.IsNotExist(err) {
@@ -620,7 +767,7 @@ func (r *resourceUtilitiesLocalDirectory) Update(ctx context.Context, req resour
 	}
 
 	// Handle file permission and ownership
-	if isProtectedPath(directoryPath) {
+	if r.policy.isProtected(directoryPath) {
 		tflog.Warn(ctx, "Skipping ownership modification for protected OS path", map[string]interface{}{
 			"path":   directoryPath,
 			"reason": "The specified path is considered critical to the operating system and should not have its ownership modified to avoid potential system instability or security risks.",
@@ -648,6 +795,27 @@ func (r *resourceUtilitiesLocalDirectory) Update(ctx context.Context, req resour
 			}
 			data.Managed = types.BoolValue(true)
 		}
+
+		// Apply ownership and permissions to the whole tree
+		if data.Recursive.ValueBool() {
+			entries, err := data.treeEntries(ctx)
+			if err == nil {
+				err = applyExtractOwnership(ctx, entries, data.treeOwnership())
+			}
+			if err != nil {
+				resp.Diagnostics.AddError(
+					"Error Setting Recursive Ownership",
+					fmt.Sprintf("Failed to apply ownership below '%s': %v", directoryPath, err),
+				)
+				return
+			}
+		}
+
+		// Apply ACLs and extended attributes, which may update the permission bits
+		applyDirectoryAttributes(ctx, &data, &state, &resp.Diagnostics)
+		if resp.Diagnostics.HasError() {
+			return
+		}
 	}
 
 	// Retrieve the current directory info
@@ -665,6 +833,7 @@ func (r *resourceUtilitiesLocalDirectory) Update(ctx context.Context, req resour
 
 	// Set data.Permissions to the current permissions
 	data.Permissions = types.StringValue(fmt.Sprintf("0%o", currentPermissions))
+	data.NonconformingEntries = types.Int64Value(0)
 
 	// Set the state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
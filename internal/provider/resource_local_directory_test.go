@@ -6,6 +6,8 @@ package provider
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"regexp"
 	"testing"
 
 	"github.com/google/uuid"
@@ -123,3 +125,288 @@ func TestResourceUtilitiesLocalDirectory(t *testing.T) {
 		},
 	})
 }
+
+func TestResourceUtilitiesLocalDirectoryDrift(t *testing.T) {
+	dirPath := filepath.Join(t.TempDir(), "drift")
+
+	config := fmt.Sprintf(`
+		resource "utilities_local_directory" "example" {
+			path        = "%s"
+			permissions = "0750"
+		}
+	`, dirPath)
+
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: TestAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("utilities_local_directory.example", "user"),
+					resource.TestCheckResourceAttrSet("utilities_local_directory.example", "group"),
+				),
+			},
+			{
+				// Permissions changed outside of Terraform are reported as drift
+				PreConfig: func() {
+					requireNoError(t, os.Chmod(dirPath, 0700))
+				},
+				Config:             config,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
+
+func TestResourceUtilitiesLocalDirectoryRecursive(t *testing.T) {
+	dirPath := filepath.Join(t.TempDir(), "app")
+	requireNoError(t, os.MkdirAll(filepath.Join(dirPath, "data", "cache"), 0700))
+	requireNoError(t, os.MkdirAll(filepath.Join(dirPath, "keep"), 0700))
+	requireNoError(t, os.WriteFile(filepath.Join(dirPath, "data", "state.db"), []byte("state"), 0600))
+	requireNoError(t, os.WriteFile(filepath.Join(dirPath, "keep", "secret"), []byte("secret"), 0600))
+
+	config := fmt.Sprintf(`
+		resource "utilities_local_directory" "example" {
+			path                  = "%s"
+			recursive             = true
+			file_permissions      = "0640"
+			directory_permissions = "0750"
+			exclude               = ["keep/**"]
+		}
+	`, dirPath)
+
+	expectMode := func(path string, want os.FileMode) error {
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("failed to stat '%s': %v", path, err)
+		}
+		if info.Mode().Perm() != want {
+			return fmt.Errorf("expected permissions %04o on '%s', got %04o", want, path, info.Mode().Perm())
+		}
+
+		return nil
+	}
+
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: TestAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("utilities_local_directory.example", "nonconforming_entries", "0"),
+					func(s *terraform.State) error {
+						if err := expectMode(filepath.Join(dirPath, "data", "cache"), 0750); err != nil {
+							return err
+						}
+						if err := expectMode(filepath.Join(dirPath, "data", "state.db"), 0640); err != nil {
+							return err
+						}
+						// Excluded entries are left alone
+						return expectMode(filepath.Join(dirPath, "keep", "secret"), 0600)
+					},
+				),
+			},
+			{
+				// Entries changed outside of Terraform are reported as drift
+				PreConfig: func() {
+					requireNoError(t, os.Chmod(filepath.Join(dirPath, "data", "state.db"), 0666))
+				},
+				Config:             config,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
+
+func TestResourceUtilitiesLocalDirectoryPathPolicy(t *testing.T) {
+	root := t.TempDir()
+	allowed := filepath.Join(root, "allowed")
+	outside := filepath.Join(root, "outside")
+	requireNoError(t, os.MkdirAll(allowed, 0755))
+	requireNoError(t, os.MkdirAll(outside, 0755))
+
+	// A link inside the allowed root must not give access to the directory it points to
+	link := filepath.Join(allowed, "link")
+	requireNoError(t, os.Symlink(outside, link))
+
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: TestAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+					provider "utilities" {
+						allowed_roots = ["%s"]
+					}
+
+					resource "utilities_local_directory" "example" {
+						path = "%s"
+					}
+				`, allowed, filepath.Join(outside, "dir")),
+				ExpectError: regexp.MustCompile(`outside the allowed roots`),
+			},
+			{
+				Config: fmt.Sprintf(`
+					provider "utilities" {
+						allowed_roots = ["%s"]
+					}
+
+					resource "utilities_local_directory" "example" {
+						path = "%s"
+					}
+				`, allowed, filepath.Join(link, "dir")),
+				ExpectError: regexp.MustCompile(`outside the allowed roots`),
+			},
+			{
+				Config: fmt.Sprintf(`
+					provider "utilities" {
+						allowed_roots = ["%s"]
+					}
+
+					resource "utilities_local_directory" "example" {
+						path = "%s"
+					}
+				`, allowed, filepath.Join(allowed, "dir")),
+				Check: resource.TestCheckResourceAttr("utilities_local_directory.example", "path", filepath.Join(allowed, "dir")),
+			},
+		},
+	})
+}
+
+func TestResourceUtilitiesLocalDirectoryBackupOnDestroy(t *testing.T) {
+	root := t.TempDir()
+	dirPath := filepath.Join(root, "data")
+	backupDir := filepath.Join(root, "backups")
+
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: TestAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+					resource "utilities_local_directory" "example" {
+						path              = "%s"
+						backup_on_destroy = true
+						backup_dir        = "%s"
+					}
+				`, dirPath, backupDir),
+				Check: func(s *terraform.State) error {
+					return os.WriteFile(filepath.Join(dirPath, "data.txt"), []byte("keep me"), 0644)
+				},
+			},
+		},
+		CheckDestroy: func(s *terraform.State) error {
+			if _, err := os.Stat(dirPath); !os.IsNotExist(err) {
+				return fmt.Errorf("expected the directory '%s' to be deleted", dirPath)
+			}
+
+			backups, err := filepath.Glob(filepath.Join(backupDir, "data-*.tar.gz"))
+			if err != nil {
+				return err
+			}
+			if len(backups) != 1 {
+				return fmt.Errorf("expected one backup in '%s', found %d", backupDir, len(backups))
+			}
+
+			return nil
+		},
+	})
+}
+
+func TestResourceUtilitiesLocalDirectoryBackupOnDestroySymlink(t *testing.T) {
+	root := t.TempDir()
+	targetPath := filepath.Join(root, "target")
+	linkPath := filepath.Join(root, "data")
+	backupDir := filepath.Join(root, "backups")
+
+	// Deleting a symbolic link leaves the directory it points to, so only the link is backed up
+	requireNoError(t, os.MkdirAll(targetPath, 0755))
+	requireNoError(t, os.WriteFile(filepath.Join(targetPath, "data.txt"), []byte("keep me"), 0644))
+	requireNoError(t, os.Symlink(targetPath, linkPath))
+
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: TestAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+					resource "utilities_local_directory" "example" {
+						path              = "%s"
+						backup_on_destroy = true
+						backup_dir        = "%s"
+					}
+				`, linkPath, backupDir),
+			},
+		},
+		CheckDestroy: func(s *terraform.State) error {
+			if _, err := os.Lstat(linkPath); !os.IsNotExist(err) {
+				return fmt.Errorf("expected the link '%s' to be deleted", linkPath)
+			}
+			if _, err := os.Stat(filepath.Join(targetPath, "data.txt")); err != nil {
+				return fmt.Errorf("expected the directory the link points to to be kept: %v", err)
+			}
+
+			backups, err := filepath.Glob(filepath.Join(backupDir, "data-*.tar.gz"))
+			if err != nil {
+				return err
+			}
+			if len(backups) != 1 {
+				return fmt.Errorf("expected one backup in '%s', found %d", backupDir, len(backups))
+			}
+
+			return nil
+		},
+	})
+}
+
+func TestResourceUtilitiesLocalDirectoryPreventDestroyIfNotEmpty(t *testing.T) {
+	dirPath := filepath.Join(t.TempDir(), "data")
+
+	config := fmt.Sprintf(`
+		resource "utilities_local_directory" "example" {
+			path                         = "%s"
+			prevent_destroy_if_not_empty = true
+		}
+	`, dirPath)
+
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: TestAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: func(s *terraform.State) error {
+					return os.WriteFile(filepath.Join(dirPath, "data.txt"), []byte("keep me"), 0644)
+				},
+			},
+			{
+				Config:      config,
+				Destroy:     true,
+				ExpectError: regexp.MustCompile(`Directory Not Empty`),
+			},
+			{
+				// Once emptied, the directory can be destroyed
+				PreConfig: func() {
+					requireNoError(t, os.Remove(filepath.Join(dirPath, "data.txt")))
+				},
+				Config: config,
+			},
+		},
+	})
+}
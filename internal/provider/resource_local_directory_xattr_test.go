@@ -0,0 +1,185 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build linux
+
+package provider
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+)
+
+func TestResourceUtilitiesLocalDirectoryACL(t *testing.T) {
+	dirPath := filepath.Join(t.TempDir(), "shared")
+
+	config := fmt.Sprintf(`
+		resource "utilities_local_directory" "example" {
+			path = "%s"
+
+			default_acl = [
+				{ tag = "group", qualifier = "daemon", permissions = "rwx" },
+			]
+
+			xattrs = {
+				"user.project" = "build"
+			}
+		}
+	`, dirPath)
+
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: TestAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("utilities_local_directory.example", "default_acl.#", "1"),
+					resource.TestCheckResourceAttr("utilities_local_directory.example", "xattrs.user.project", "build"),
+					func(s *terraform.State) error {
+						encoded, err := getXattr(dirPath, xattrACLDefault)
+						if xattrUnsupported(err) {
+							// The filesystem does not support ACLs; a warning was reported instead
+							return nil
+						}
+						if err != nil {
+							return fmt.Errorf("failed to read default ACL: %v", err)
+						}
+						entries, err := decodePosixACL(encoded)
+						if err != nil {
+							return err
+						}
+						// The owner, owning group, other and mask entries are completed
+						if len(entries) != 5 {
+							return fmt.Errorf("expected 5 default ACL entries, got %d", len(entries))
+						}
+
+						return nil
+					},
+				),
+			},
+			{
+				// Extended attributes removed outside of Terraform are reported as drift
+				PreConfig: func() {
+					if err := removeXattr(dirPath, "user.project"); err != nil && !xattrUnsupported(err) {
+						t.Fatalf("failed to remove extended attribute: %v", err)
+					}
+				},
+				Config:             config,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				// Removing the default ACL from the configuration removes it from the directory
+				Config: fmt.Sprintf(`
+					resource "utilities_local_directory" "example" {
+						path = "%s"
+					}
+				`, dirPath),
+				Check: func(s *terraform.State) error {
+					if _, err := getXattr(dirPath, xattrACLDefault); err == nil {
+						return fmt.Errorf("expected the default ACL to be removed")
+					} else if !xattrNotFound(err) && !xattrUnsupported(err) {
+						return fmt.Errorf("failed to read default ACL: %v", err)
+					}
+
+					return nil
+				},
+			},
+		},
+	})
+}
+
+func TestResourceUtilitiesLocalDirectoryAccessACL(t *testing.T) {
+	dirPath := filepath.Join(t.TempDir(), "shared")
+
+	config := func(mask string) string {
+		return fmt.Sprintf(`
+			resource "utilities_local_directory" "example" {
+				path        = "%s"
+				permissions = "0750"
+
+				acl = [
+					{ tag = "group", qualifier = "daemon", permissions = "rwx" },
+					%s
+				]
+			}
+		`, dirPath, mask)
+	}
+
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: TestAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				// A mask that contradicts the group bits of the permissions is rejected
+				Config:      config(`{ tag = "mask", permissions = "rwx" },`),
+				ExpectError: regexp.MustCompile("Conflicting ACL and Permissions"),
+			},
+			{
+				// The named entry does not widen the configured permissions
+				Config: config(""),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("utilities_local_directory.example", "permissions", "0750"),
+					resource.TestCheckResourceAttr("utilities_local_directory.example", "acl.#", "1"),
+					func(s *terraform.State) error {
+						encoded, err := getXattr(dirPath, xattrACLAccess)
+						if xattrUnsupported(err) {
+							// The filesystem does not support ACLs; a warning was reported instead
+							return nil
+						}
+						if err != nil {
+							return fmt.Errorf("failed to read access ACL: %v", err)
+						}
+						entries, err := decodePosixACL(encoded)
+						if err != nil {
+							return err
+						}
+						for _, entry := range entries {
+							if entry.tag == aclMask && entry.perm != 5 {
+								return fmt.Errorf("expected mask r-x, got %s", formatACLPermissions(entry.perm))
+							}
+						}
+
+						return nil
+					},
+				),
+			},
+			{
+				Config:   config(""),
+				PlanOnly: true,
+			},
+			{
+				// Removing the access ACL from the configuration leaves the permissions alone
+				Config: fmt.Sprintf(`
+					resource "utilities_local_directory" "example" {
+						path        = "%s"
+						permissions = "0750"
+					}
+				`, dirPath),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("utilities_local_directory.example", "permissions", "0750"),
+					func(s *terraform.State) error {
+						if _, err := getXattr(dirPath, xattrACLAccess); err == nil {
+							return fmt.Errorf("expected the access ACL to be removed")
+						} else if !xattrNotFound(err) && !xattrUnsupported(err) {
+							return fmt.Errorf("failed to read access ACL: %v", err)
+						}
+
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
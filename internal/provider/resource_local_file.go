@@ -0,0 +1,404 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	"terraform-provider-utilities/internal/fsmeta"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+var (
+	_ resource.Resource               = (*resourceUtilitiesLocalFile)(nil)
+	_ resource.ResourceWithConfigure  = (*resourceUtilitiesLocalFile)(nil)
+	_ resource.ResourceWithModifyPlan = (*resourceUtilitiesLocalFile)(nil)
+)
+
+type resourceUtilitiesLocalFile struct {
+	policy *pathPolicy
+}
+
+type LocalFile struct {
+	Path             types.String `tfsdk:"path"`
+	Content          types.String `tfsdk:"content"`
+	ContentBase64    types.String `tfsdk:"content_base64"`
+	SensitiveContent types.String `tfsdk:"sensitive_content"`
+	Source           types.String `tfsdk:"source"`
+	User             types.String `tfsdk:"user"`
+	Group            types.String `tfsdk:"group"`
+	Permissions      types.String `tfsdk:"permissions"`
+	ContentSHA256    types.String `tfsdk:"content_sha256"`
+}
+
+// contentReader returns the configured content, whichever attribute it is set with.
+func (f LocalFile) contentReader() (io.ReadCloser, error) {
+	switch {
+	case !f.Content.IsNull():
+		return io.NopCloser(bytes.NewBufferString(f.Content.ValueString())), nil
+	case !f.SensitiveContent.IsNull():
+		return io.NopCloser(bytes.NewBufferString(f.SensitiveContent.ValueString())), nil
+	case !f.ContentBase64.IsNull():
+		decoded, err := base64.StdEncoding.DecodeString(f.ContentBase64.ValueString())
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode content_base64: %w", err)
+		}
+		return io.NopCloser(bytes.NewReader(decoded)), nil
+	case !f.Source.IsNull():
+		return os.Open(f.Source.ValueString())
+	}
+
+	return nil, fmt.Errorf("no content configured")
+}
+
+// contentKnown reports whether the content is known, so that its hash can be planned.
+func (f LocalFile) contentKnown() bool {
+	return !f.Content.IsUnknown() && !f.SensitiveContent.IsUnknown() && !f.ContentBase64.IsUnknown() && !f.Source.IsUnknown()
+}
+
+// NewResourceUtilitiesLocalFile creates a new instance of the resource.
+func NewResourceUtilitiesLocalFile() resource.Resource {
+	return &resourceUtilitiesLocalFile{}
+}
+
+func (r *resourceUtilitiesLocalFile) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.policy = providerPathPolicy(req.ProviderData)
+}
+
+// Metadata sets the resource type name.
+func (r *resourceUtilitiesLocalFile) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = "utilities_local_file"
+}
+
+func (r *resourceUtilitiesLocalFile) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: `
+The **utilities_local_file** resource manages a local file, including its owner, group and permissions.
+
+- **Content**: The content is set with exactly one of **content**, **content_base64**, **sensitive_content** or **source**.
+- **Atomic Writes**: The content is written to a temporary file next to the destination, which is then renamed into place, so that readers never see a partially written file.
+- **Drift Detection**: Content, ownership or permissions changed outside of Terraform are reported as drift. Content is compared by its SHA-256 hash.
+`,
+		Attributes: map[string]schema.Attribute{
+			"path": schema.StringAttribute{
+				Required:            true,
+				MarkdownDescription: "Path of the file to manage. Missing parent directories are created.",
+			},
+			"content": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Content of the file, as a UTF-8 string.",
+				Validators: []validator.String{
+					stringvalidator.ExactlyOneOf(
+						path.MatchRelative().AtParent().AtName("content_base64"),
+						path.MatchRelative().AtParent().AtName("sensitive_content"),
+						path.MatchRelative().AtParent().AtName("source"),
+					),
+				},
+			},
+			"content_base64": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Content of the file, base64 encoded. Use it for binary content.",
+			},
+			"sensitive_content": schema.StringAttribute{
+				Optional:            true,
+				Sensitive:           true,
+				MarkdownDescription: "Content of the file, as a UTF-8 string that is not shown in plans or logs.",
+			},
+			"source": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Path of a file whose content is copied.",
+			},
+			"user": schema.StringAttribute{
+				Optional:            true,
+				Computed:            true,
+				MarkdownDescription: "User to own the file. Defaults to the current system user if not specified.",
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"group": schema.StringAttribute{
+				Optional:            true,
+				Computed:            true,
+				MarkdownDescription: "Group to own the file. Defaults to the current user's group if not specified.",
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"permissions": schema.StringAttribute{
+				Optional:            true,
+				Computed:            true,
+				MarkdownDescription: "Permissions to set on the file, in octal format (e.g., 0644). Defaults to 0644.",
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(regexp.MustCompile(`^0[0-7]{3}$`), "must be a valid octal permission (e.g., 0644)"),
+				},
+			},
+			"content_sha256": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "The SHA-256 hash of the content of the file, used to detect changes made outside of Terraform.",
+			},
+		},
+	}
+}
+
+// ModifyPlan plans the hash of the configured content, so that a file modified outside of
+// Terraform shows up as a diff.
+func (r *resourceUtilitiesLocalFile) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to do on destroy
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var plan LocalFile
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	plan.ContentSHA256 = types.StringUnknown()
+	if plan.contentKnown() {
+		if reader, err := plan.contentReader(); err == nil {
+			hash := sha256.New()
+			if _, err := io.Copy(hash, reader); err == nil {
+				plan.ContentSHA256 = types.StringValue(fmt.Sprintf("%x", hash.Sum(nil)))
+			}
+			_ = reader.Close()
+		}
+	}
+
+	resp.Diagnostics.Append(resp.Plan.Set(ctx, &plan)...)
+}
+
+func (r *resourceUtilitiesLocalFile) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data LocalFile
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r.write(ctx, &data, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *resourceUtilitiesLocalFile) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data LocalFile
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	filePath := data.Path.ValueString()
+	info, err := os.Stat(filePath)
+	if os.IsNotExist(err) {
+		tflog.Warn(ctx, "File does not exist, removing it from the state", map[string]interface{}{"path": filePath})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error Retrieving File Info",
+			fmt.Sprintf("Failed to check the path '%s': %v", filePath, err),
+		)
+		return
+	}
+
+	hash, err := calculateFileHash(filePath)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"File Hash Calculation Failed",
+			fmt.Sprintf("Error calculating hash for file '%s': %v", filePath, err),
+		)
+		return
+	}
+	data.ContentSHA256 = types.StringValue(hash)
+
+	// Read the actual owner and group so that manual changes show up as drift
+	if uid, gid, ok := fsmeta.Ownership(info); ok {
+		if !ownerMatchesUID(data.User.ValueString(), uid) {
+			data.User = types.StringValue(fsmeta.UserName(uid))
+		}
+		if !groupMatchesGID(data.Group.ValueString(), gid) {
+			data.Group = types.StringValue(fsmeta.GroupName(gid))
+		}
+	}
+	data.Permissions = types.StringValue(fmt.Sprintf("%04o", info.Mode().Perm()))
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *resourceUtilitiesLocalFile) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state LocalFile
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r.write(ctx, &plan, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The file was moved, remove it from its previous path
+	if previous := state.Path.ValueString(); previous != plan.Path.ValueString() {
+		r.remove(ctx, previous, &resp.Diagnostics)
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *resourceUtilitiesLocalFile) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data LocalFile
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r.remove(ctx, data.Path.ValueString(), &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.State.RemoveResource(ctx)
+}
+
+// write atomically writes the configured content with the configured ownership and permissions,
+// and records the resolved owner, permissions and content hash in data.
+func (r *resourceUtilitiesLocalFile) write(ctx context.Context, data *LocalFile, diagnostics *diag.Diagnostics) {
+	filePath := data.Path.ValueString()
+
+	// Refuse paths that the provider's path policy does not allow
+	if err := r.policy.checkAllowed(filePath); err != nil {
+		diagnostics.AddError(
+			"Path Not Allowed",
+			fmt.Sprintf("Refusing to write '%s': %v", filePath, err),
+		)
+		return
+	}
+
+	userName, groupName, uid, gid := resolveOwnership(data.User.ValueString(), data.Group.ValueString(), diagnostics)
+	if diagnostics.HasError() {
+		return
+	}
+
+	permissions := data.Permissions.ValueString()
+	if permissions == "" {
+		permissions = "0644"
+	}
+	mode, err := strconv.ParseUint(permissions, 8, 32)
+	if err != nil {
+		diagnostics.AddError("Invalid Permissions", fmt.Sprintf("Failed to convert permissions '%s': %v", permissions, err))
+		return
+	}
+
+	reader, err := data.contentReader()
+	if err != nil {
+		diagnostics.AddError(
+			"Error Reading Content",
+			fmt.Sprintf("Failed to read the content of '%s': %v", filePath, err),
+		)
+		return
+	}
+	defer func() { _ = reader.Close() }()
+
+	hash, size, err := writeFileAtomic(filePath, reader, os.FileMode(mode), uid, gid)
+	if err != nil {
+		diagnostics.AddError(
+			"Error Writing File",
+			fmt.Sprintf("Failed to write file '%s': %v", filePath, err),
+		)
+		return
+	}
+
+	tflog.Debug(ctx, "Wrote local file", map[string]interface{}{
+		"path":        filePath,
+		"user":        userName,
+		"group":       groupName,
+		"permissions": permissions,
+		"size":        size,
+	})
+
+	data.User = types.StringValue(userName)
+	data.Group = types.StringValue(groupName)
+	data.Permissions = types.StringValue(permissions)
+	data.ContentSHA256 = types.StringValue(hash)
+}
+
+// remove deletes a managed file. Protected paths are never deleted.
+func (r *resourceUtilitiesLocalFile) remove(ctx context.Context, filePath string, diagnostics *diag.Diagnostics) {
+	if r.policy.isProtected(filePath) {
+		tflog.Warn(ctx, "Attempted to delete a protected path, skipping deletion", map[string]interface{}{"path": filePath})
+		return
+	}
+
+	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+		diagnostics.AddError(
+			"Error Deleting File",
+			fmt.Sprintf("Failed to delete file '%s': %v", filePath, err),
+		)
+	}
+}
+
+// writeFileAtomic writes content to a temporary file next to destination, sets its mode and
+// ownership and renames it into place, so that the destination is never left partially written.
+// An ID of -1 leaves the owner or group unchanged. It returns the SHA-256 and size of the content.
+func writeFileAtomic(destination string, content io.Reader, mode os.FileMode, uid, gid int) (string, int64, error) {
+	if err := os.MkdirAll(filepath.Dir(destination), 0755); err != nil {
+		return "", 0, fmt.Errorf("failed to create directory '%s': %w", filepath.Dir(destination), err)
+	}
+
+	output, err := os.CreateTemp(filepath.Dir(destination), "."+filepath.Base(destination)+".*")
+	if err != nil {
+		return "", 0, err
+	}
+	defer func() { _ = os.Remove(output.Name()) }()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(output, hash), content)
+	if err != nil {
+		_ = output.Close()
+		return "", 0, err
+	}
+	if err := output.Sync(); err != nil {
+		_ = output.Close()
+		return "", 0, err
+	}
+	if err := output.Close(); err != nil {
+		return "", 0, err
+	}
+
+	if err := os.Chmod(output.Name(), mode); err != nil {
+		return "", 0, err
+	}
+	if uid != -1 || gid != -1 {
+		if err := os.Chown(output.Name(), uid, gid); err != nil {
+			return "", 0, fmt.Errorf("failed to set ownership: %w", err)
+		}
+	}
+	if err := os.Rename(output.Name(), destination); err != nil {
+		return "", 0, err
+	}
+
+	return fmt.Sprintf("%x", hash.Sum(nil)), size, nil
+}
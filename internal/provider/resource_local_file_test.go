@@ -0,0 +1,88 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+)
+
+func TestResourceUtilitiesLocalFile(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "conf", "app.conf")
+
+	config := func(content string) string {
+		return fmt.Sprintf(`
+			resource "utilities_local_file" "example" {
+				path        = "%s"
+				content     = "%s"
+				permissions = "0640"
+			}
+		`, filePath, content)
+	}
+
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: TestAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config("listen = 8080"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("utilities_local_file.example", "permissions", "0640"),
+					resource.TestCheckResourceAttr("utilities_local_file.example", "content_sha256", "9e98e8eeb487189cffc6cde57de3d904dbb726e58dd99ae29ede67afa87cdcfb"),
+					resource.TestCheckResourceAttrSet("utilities_local_file.example", "user"),
+					resource.TestCheckResourceAttrSet("utilities_local_file.example", "group"),
+					func(s *terraform.State) error {
+						content, err := os.ReadFile(filePath)
+						if err != nil {
+							return err
+						}
+						if string(content) != "listen = 8080" {
+							return fmt.Errorf("unexpected content '%s'", content)
+						}
+						info, err := os.Stat(filePath)
+						if err != nil {
+							return err
+						}
+						if info.Mode().Perm() != 0640 {
+							return fmt.Errorf("expected permissions 0640, got %04o", info.Mode().Perm())
+						}
+
+						return nil
+					},
+				),
+			},
+			{
+				// Content changed outside of Terraform is reported as drift
+				PreConfig: func() {
+					requireNoError(t, os.WriteFile(filePath, []byte("listen = 9090"), 0640))
+				},
+				Config:             config("listen = 8080"),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				Config: config("listen = 8081"),
+				Check: func(s *terraform.State) error {
+					content, err := os.ReadFile(filePath)
+					if err != nil {
+						return err
+					}
+					if string(content) != "listen = 8081" {
+						return fmt.Errorf("unexpected content '%s'", content)
+					}
+
+					return nil
+				},
+			},
+		},
+	})
+}
@@ -0,0 +1,268 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+var (
+	_ resource.Resource              = (*resourceUtilitiesLocalSymlink)(nil)
+	_ resource.ResourceWithConfigure = (*resourceUtilitiesLocalSymlink)(nil)
+)
+
+type resourceUtilitiesLocalSymlink struct {
+	policy *pathPolicy
+}
+
+type LocalSymlink struct {
+	Path   types.String `tfsdk:"path"`
+	Target types.String `tfsdk:"target"`
+	Force  types.Bool   `tfsdk:"force"`
+}
+
+// NewResourceUtilitiesLocalSymlink creates a new instance of the resource.
+func NewResourceUtilitiesLocalSymlink() resource.Resource {
+	return &resourceUtilitiesLocalSymlink{}
+}
+
+func (r *resourceUtilitiesLocalSymlink) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.policy = providerPathPolicy(req.ProviderData)
+}
+
+// Metadata sets the resource type name.
+func (r *resourceUtilitiesLocalSymlink) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = "utilities_local_symlink"
+}
+
+func (r *resourceUtilitiesLocalSymlink) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: `
+The **utilities_local_symlink** resource manages a symbolic link, such as a link to the current release of an application.
+
+- **Atomic Replacement**: The link is created under a temporary name next to **path** and renamed into place, so that the path always resolves to either the old or the new target.
+- **Drift Detection**: A link that is retargeted, removed or replaced by a regular file outside of Terraform is reported as drift.
+- **Protected Paths**: Links are never created at, or deleted from, paths protected by the provider configuration.
+`,
+		Attributes: map[string]schema.Attribute{
+			"path": schema.StringAttribute{
+				Required:            true,
+				MarkdownDescription: "Path of the symbolic link. Missing parent directories are created.",
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"target": schema.StringAttribute{
+				Required:            true,
+				MarkdownDescription: "Path the link points to. Relative targets are resolved from the directory containing the link, and the target does not need to exist.",
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"force": schema.BoolAttribute{
+				Optional:            true,
+				MarkdownDescription: "Whether to replace a regular file found at **path**. Directories are never replaced. Default is false.",
+			},
+		},
+	}
+}
+
+func (r *resourceUtilitiesLocalSymlink) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data LocalSymlink
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r.link(ctx, data, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *resourceUtilitiesLocalSymlink) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data LocalSymlink
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	linkPath := data.Path.ValueString()
+	info, err := os.Lstat(linkPath)
+	if os.IsNotExist(err) {
+		tflog.Warn(ctx, "Symbolic link does not exist, removing it from the state", map[string]interface{}{"path": linkPath})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Error Retrieving Path Info",
+			fmt.Sprintf("Failed to check the path '%s': %v", linkPath, err),
+		)
+		return
+	}
+
+	if info.Mode()&os.ModeSymlink == 0 {
+		// An empty target can never match the configuration, so the link is planned again
+		data.Target = types.StringValue("")
+		resp.Diagnostics.AddWarning(
+			"Symlink Drift Detected",
+			fmt.Sprintf("The path '%s' is no longer a symbolic link, marking the resource for update.", linkPath),
+		)
+	} else {
+		target, err := os.Readlink(linkPath)
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Error Reading Symbolic Link",
+				fmt.Sprintf("Failed to read the symbolic link '%s': %v", linkPath, err),
+			)
+			return
+		}
+		data.Target = types.StringValue(target)
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *resourceUtilitiesLocalSymlink) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state LocalSymlink
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r.link(ctx, plan, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The link was moved, remove it from its previous path
+	if previous := state.Path.ValueString(); previous != plan.Path.ValueString() {
+		r.unlink(ctx, previous, &resp.Diagnostics)
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *resourceUtilitiesLocalSymlink) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data LocalSymlink
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r.unlink(ctx, data.Path.ValueString(), &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.State.RemoveResource(ctx)
+}
+
+// link atomically points the link at its target, replacing an existing link or, with force, a
+// regular file.
+func (r *resourceUtilitiesLocalSymlink) link(ctx context.Context, data LocalSymlink, diagnostics *diag.Diagnostics) {
+	linkPath := data.Path.ValueString()
+	target := data.Target.ValueString()
+
+	// Refuse paths that the provider's path policy does not allow
+	if err := r.policy.checkAllowedLink(linkPath); err != nil {
+		diagnostics.AddError(
+			"Path Not Allowed",
+			fmt.Sprintf("Refusing to create a symbolic link at '%s': %v", linkPath, err),
+		)
+		return
+	}
+
+	if info, err := os.Lstat(linkPath); err == nil && info.Mode()&os.ModeSymlink == 0 {
+		if info.IsDir() || !data.Force.ValueBool() {
+			diagnostics.AddError(
+				"Path Exists",
+				fmt.Sprintf("The path '%s' exists and is not a symbolic link. Set force to replace a regular file; directories are never replaced.", linkPath),
+			)
+			return
+		}
+	} else if err != nil && !os.IsNotExist(err) {
+		diagnostics.AddError(
+			"Error Retrieving Path Info",
+			fmt.Sprintf("Failed to check the path '%s': %v", linkPath, err),
+		)
+		return
+	}
+
+	if err := replaceSymlink(target, linkPath); err != nil {
+		diagnostics.AddError(
+			"Error Creating Symbolic Link",
+			fmt.Sprintf("Failed to link '%s' to '%s': %v", linkPath, target, err),
+		)
+		return
+	}
+
+	tflog.Debug(ctx, "Created symbolic link", map[string]interface{}{
+		"path":   linkPath,
+		"target": target,
+	})
+}
+
+// unlink removes a managed link. Protected paths, and paths that are no longer symbolic links,
+// are left in place.
+func (r *resourceUtilitiesLocalSymlink) unlink(ctx context.Context, linkPath string, diagnostics *diag.Diagnostics) {
+	if r.policy.isProtectedLink(linkPath) {
+		tflog.Warn(ctx, "Attempted to delete a protected path, skipping deletion", map[string]interface{}{"path": linkPath})
+		return
+	}
+
+	info, err := os.Lstat(linkPath)
+	if os.IsNotExist(err) {
+		return
+	}
+	if err == nil && info.Mode()&os.ModeSymlink == 0 {
+		diagnostics.AddWarning(
+			"Symbolic Link Not Deleted",
+			fmt.Sprintf("The path '%s' is no longer a symbolic link and was left in place.", linkPath),
+		)
+		return
+	}
+
+	if err := os.Remove(linkPath); err != nil && !os.IsNotExist(err) {
+		diagnostics.AddError(
+			"Error Deleting Symbolic Link",
+			fmt.Sprintf("Failed to delete the symbolic link '%s': %v", linkPath, err),
+		)
+	}
+}
+
+// replaceSymlink creates a link to target under a temporary name next to linkPath and renames it
+// over linkPath, which atomically replaces an existing link or file.
+func replaceSymlink(target, linkPath string) error {
+	if err := os.MkdirAll(filepath.Dir(linkPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory '%s': %w", filepath.Dir(linkPath), err)
+	}
+
+	tmpPath := filepath.Join(filepath.Dir(linkPath), "."+filepath.Base(linkPath)+"."+uuid.NewString())
+	if err := os.Symlink(target, tmpPath); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, linkPath); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+
+	return nil
+}
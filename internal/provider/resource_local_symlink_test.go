@@ -0,0 +1,84 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+)
+
+func TestResourceUtilitiesLocalSymlink(t *testing.T) {
+	appDir := t.TempDir()
+	linkPath := filepath.Join(appDir, "current")
+	release1 := filepath.Join(appDir, "releases", "1.2.3")
+	release2 := filepath.Join(appDir, "releases", "1.2.4")
+	requireNoError(t, os.MkdirAll(release1, 0755))
+	requireNoError(t, os.MkdirAll(release2, 0755))
+
+	config := func(target string) string {
+		return fmt.Sprintf(`
+			resource "utilities_local_symlink" "current" {
+				path   = "%s"
+				target = "%s"
+			}
+		`, linkPath, target)
+	}
+
+	checkTarget := func(expected string) resource.TestCheckFunc {
+		return func(s *terraform.State) error {
+			target, err := os.Readlink(linkPath)
+			if err != nil {
+				return err
+			}
+			if target != expected {
+				return fmt.Errorf("expected link to '%s', got '%s'", expected, target)
+			}
+
+			return nil
+		}
+	}
+
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: TestAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config(release1),
+				Check:  checkTarget(release1),
+			},
+			{
+				Config: config(release2),
+				Check:  checkTarget(release2),
+			},
+			{
+				// A link retargeted outside of Terraform is reported as drift
+				PreConfig: func() {
+					requireNoError(t, os.Remove(linkPath))
+					requireNoError(t, os.Symlink(release1, linkPath))
+				},
+				Config:             config(release2),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				// A regular file is only replaced with force
+				PreConfig: func() {
+					requireNoError(t, os.Remove(linkPath))
+					requireNoError(t, os.WriteFile(linkPath, []byte("not a link"), 0644))
+				},
+				Config:      config(release2),
+				ExpectError: regexp.MustCompile(`is not a symbolic link`),
+			},
+		},
+	})
+}
@@ -0,0 +1,329 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+var (
+	_ resource.Resource               = (*resourceUtilitiesTemplateDirectory)(nil)
+	_ resource.ResourceWithConfigure  = (*resourceUtilitiesTemplateDirectory)(nil)
+	_ resource.ResourceWithModifyPlan = (*resourceUtilitiesTemplateDirectory)(nil)
+)
+
+type resourceUtilitiesTemplateDirectory struct {
+	policy *pathPolicy
+}
+
+type TemplateDirectory struct {
+	SourceDir      types.String `tfsdk:"source_dir"`
+	DestinationDir types.String `tfsdk:"destination_dir"`
+	Vars           types.Map    `tfsdk:"vars"`
+	Helpers        types.Bool   `tfsdk:"helpers"`
+	CreatedFiles   types.List   `tfsdk:"created_files"`
+	FileHashes     types.Map    `tfsdk:"file_hashes"`
+}
+
+// render renders the source directory with the configured variables.
+func (d TemplateDirectory) render(ctx context.Context) ([]renderedEntry, error) {
+	vars := map[string]string{}
+	if !d.Vars.IsNull() {
+		if diags := d.Vars.ElementsAs(ctx, &vars, false); diags.HasError() {
+			return nil, fmt.Errorf("failed to read vars")
+		}
+	}
+
+	return renderTemplateDirectory(d.SourceDir.ValueString(), d.DestinationDir.ValueString(), vars, d.Helpers.ValueBool())
+}
+
+// setRendered records the created paths and the hashes of the rendered files.
+func (d *TemplateDirectory) setRendered(entries []renderedEntry) {
+	createdFiles := []attr.Value{}
+	hashes := map[string]attr.Value{}
+	for _, entry := range entries {
+		createdFiles = append(createdFiles, types.StringValue(entry.Path))
+		if !entry.IsDir {
+			hashes[entry.RelPath] = types.StringValue(entry.SHA256)
+		}
+	}
+
+	d.CreatedFiles = types.ListValueMust(types.StringType, createdFiles)
+	d.FileHashes = types.MapValueMust(types.StringType, hashes)
+}
+
+// NewResourceUtilitiesTemplateDirectory creates a new instance of the resource.
+func NewResourceUtilitiesTemplateDirectory() resource.Resource {
+	return &resourceUtilitiesTemplateDirectory{}
+}
+
+func (r *resourceUtilitiesTemplateDirectory) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.policy = providerPathPolicy(req.ProviderData)
+}
+
+// Metadata sets the resource type name.
+func (r *resourceUtilitiesTemplateDirectory) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = "utilities_template_directory"
+}
+
+func (r *resourceUtilitiesTemplateDirectory) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: `
+The **utilities_template_directory** resource renders a directory of templates into a destination directory.
+
+- **Templates**: Every file ending in **.tmpl** is rendered with Go [text/template](https://pkg.go.dev/text/template), with **vars** as the data (e.g., ` + "`{{ .name }}`" + `), and written without the suffix. Referencing a variable that is not set is an error; use ` + "`{{ index . \"name\" }}`" + ` for optional variables.
+- **Other Files**: Files without the suffix are copied verbatim. The relative layout and the permissions of the source files are preserved.
+- **Drift Detection**: Rendered files that are modified or removed outside of Terraform are written again.
+`,
+		Attributes: map[string]schema.Attribute{
+			"source_dir": schema.StringAttribute{
+				Required:    true,
+				Description: "The directory containing the templates and files to render.",
+			},
+			"destination_dir": schema.StringAttribute{
+				Required:    true,
+				Description: "The directory the rendered tree is written to. Missing directories are created.",
+			},
+			"vars": schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Description: "Variables available to the templates.",
+			},
+			"helpers": schema.BoolAttribute{
+				Optional:    true,
+				Description: "Whether to make Sprig-style helper functions available to the templates: `upper`, `lower`, `trim`, `trimPrefix`, `trimSuffix`, `replace`, `contains`, `hasPrefix`, `hasSuffix`, `splitList`, `join`, `quote`, `squote`, `indent`, `nindent`, `b64enc`, `b64dec`, `sha256sum`, `toJson`, `default` and `required`. Default is false.",
+			},
+			"created_files": schema.ListAttribute{
+				ElementType: types.StringType,
+				Computed:    true,
+				Description: "The directories and files created in the destination directory, in the order they were written.",
+			},
+			"file_hashes": schema.MapAttribute{
+				ElementType: types.StringType,
+				Computed:    true,
+				Description: "The SHA-256 hash of each rendered file, keyed by its path relative to the destination directory. Used to detect files changed outside of Terraform.",
+			},
+		},
+	}
+}
+
+// ModifyPlan renders the templates during plan, so that rendering errors are reported early and
+// files that drifted or whose templates or variables changed produce a diff.
+func (r *resourceUtilitiesTemplateDirectory) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to do on destroy
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var data TemplateDirectory
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.CreatedFiles = types.ListUnknown(types.StringType)
+	data.FileHashes = types.MapUnknown(types.StringType)
+	if !data.SourceDir.IsUnknown() && !data.DestinationDir.IsUnknown() && !data.Vars.IsUnknown() && !data.Helpers.IsUnknown() {
+		entries, err := data.render(ctx)
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Template Rendering Failed",
+				fmt.Sprintf("Error rendering '%s': %v", data.SourceDir.ValueString(), err),
+			)
+			return
+		}
+		data.setRendered(entries)
+	}
+
+	resp.Diagnostics.Append(resp.Plan.Set(ctx, &data)...)
+}
+
+func (r *resourceUtilitiesTemplateDirectory) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data TemplateDirectory
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r.write(ctx, &data, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *resourceUtilitiesTemplateDirectory) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data TemplateDirectory
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	hashes := map[string]string{}
+	resp.Diagnostics.Append(data.FileHashes.ElementsAs(ctx, &hashes, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Detect rendered files that were modified or removed
+	var drifted []string
+	for relPath, expected := range hashes {
+		hash, err := calculateFileHash(templateDestination(data.DestinationDir.ValueString(), relPath))
+		if err != nil || hash != expected {
+			hashes[relPath] = ""
+			drifted = append(drifted, relPath)
+		}
+	}
+
+	if len(drifted) > 0 {
+		sort.Strings(drifted)
+		resp.Diagnostics.AddWarning(
+			"Template Drift Detected",
+			fmt.Sprintf("The rendered files %s no longer match their templates, marking the resource for update.", strings.Join(drifted, ", ")),
+		)
+
+		value, diags := types.MapValueFrom(ctx, types.StringType, hashes)
+		resp.Diagnostics.Append(diags...)
+		data.FileHashes = value
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *resourceUtilitiesTemplateDirectory) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state TemplateDirectory
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r.write(ctx, &plan, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Remove the paths that are no longer part of the rendered tree
+	var previous, current []string
+	resp.Diagnostics.Append(state.CreatedFiles.ElementsAs(ctx, &previous, false)...)
+	resp.Diagnostics.Append(plan.CreatedFiles.ElementsAs(ctx, &current, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	keep := map[string]bool{}
+	for _, p := range current {
+		keep[p] = true
+	}
+	var stale []string
+	for _, p := range previous {
+		if !keep[p] {
+			stale = append(stale, p)
+		}
+	}
+	removeTemplateFiles(stale, &resp.Diagnostics)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *resourceUtilitiesTemplateDirectory) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data TemplateDirectory
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var createdFiles []string
+	resp.Diagnostics.Append(data.CreatedFiles.ElementsAs(ctx, &createdFiles, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	removeTemplateFiles(createdFiles, &resp.Diagnostics)
+
+	resp.State.RemoveResource(ctx)
+}
+
+// write renders the templates into the destination directory and records the result in data.
+func (r *resourceUtilitiesTemplateDirectory) write(ctx context.Context, data *TemplateDirectory, diagnostics *diag.Diagnostics) {
+	destination := data.DestinationDir.ValueString()
+
+	// Refuse destinations that the provider's path policy does not allow
+	if err := r.policy.checkAllowed(destination); err != nil {
+		diagnostics.AddError(
+			"Destination Not Allowed",
+			fmt.Sprintf("Refusing to render to '%s': %v", destination, err),
+		)
+		return
+	}
+
+	entries, err := data.render(ctx)
+	if err != nil {
+		diagnostics.AddError(
+			"Template Rendering Failed",
+			fmt.Sprintf("Error rendering '%s': %v", data.SourceDir.ValueString(), err),
+		)
+		return
+	}
+
+	if err := os.MkdirAll(destination, 0755); err != nil {
+		diagnostics.AddError(
+			"Error Creating Directory",
+			fmt.Sprintf("Failed to create directory '%s': %v", destination, err),
+		)
+		return
+	}
+	if err := writeRenderedEntries(entries); err != nil {
+		diagnostics.AddError(
+			"Error Writing Rendered Files",
+			fmt.Sprintf("Failed to write the rendered tree to '%s': %v", destination, err),
+		)
+		return
+	}
+
+	tflog.Debug(ctx, "Rendered template directory", map[string]interface{}{
+		"source":      data.SourceDir.ValueString(),
+		"destination": destination,
+		"entries":     len(entries),
+	})
+
+	data.setRendered(entries)
+}
+
+// templateDestination returns the destination path of a file recorded in file_hashes.
+func templateDestination(destination, relPath string) string {
+	return filepath.Join(destination, filepath.FromSlash(relPath))
+}
+
+// removeTemplateFiles deletes created paths in reverse order, so that directories are emptied
+// before they are removed. Directories that still hold other files are kept.
+func removeTemplateFiles(paths []string, diagnostics *diag.Diagnostics) {
+	for i := len(paths) - 1; i >= 0; i-- {
+		p := paths[i]
+
+		if info, err := os.Stat(p); err == nil && info.IsDir() {
+			if entries, err := os.ReadDir(p); err != nil || len(entries) > 0 {
+				continue
+			}
+		}
+
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			diagnostics.AddWarning(
+				"File Deletion Failed",
+				fmt.Sprintf("Could not delete file '%s': %v", p, err),
+			)
+		}
+	}
+}
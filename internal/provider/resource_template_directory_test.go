@@ -0,0 +1,119 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+)
+
+func TestResourceUtilitiesTemplateDirectory(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "templates")
+	destinationDir := filepath.Join(tempDir, "rendered")
+
+	requireNoError(t, os.MkdirAll(filepath.Join(sourceDir, "conf.d"), 0755))
+	requireNoError(t, os.WriteFile(filepath.Join(sourceDir, "app.conf.tmpl"), []byte("name = {{ .name | upper }}\n"), 0644))
+	requireNoError(t, os.WriteFile(filepath.Join(sourceDir, "conf.d", "static.conf"), []byte("{{ .verbatim }}\n"), 0644))
+
+	config := fmt.Sprintf(`
+		resource "utilities_template_directory" "example" {
+			source_dir      = "%s"
+			destination_dir = "%s"
+			helpers         = true
+
+			vars = {
+				name = "web"
+			}
+		}
+	`, sourceDir, destinationDir)
+
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: TestAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("utilities_template_directory.example", "created_files.#", "3"),
+					resource.TestCheckResourceAttr("utilities_template_directory.example", "created_files.0", filepath.Join(destinationDir, "app.conf")),
+					resource.TestCheckResourceAttrSet("utilities_template_directory.example", "file_hashes.app.conf"),
+					resource.TestCheckResourceAttrSet("utilities_template_directory.example", "file_hashes.conf.d/static.conf"),
+					func(s *terraform.State) error {
+						content, err := os.ReadFile(filepath.Join(destinationDir, "app.conf"))
+						if err != nil {
+							return err
+						}
+						if string(content) != "name = WEB\n" {
+							return fmt.Errorf("unexpected rendered content '%s'", content)
+						}
+						content, err = os.ReadFile(filepath.Join(destinationDir, "conf.d", "static.conf"))
+						if err != nil {
+							return err
+						}
+						if string(content) != "{{ .verbatim }}\n" {
+							return fmt.Errorf("expected the file to be copied verbatim, got '%s'", content)
+						}
+
+						return nil
+					},
+				),
+			},
+			{
+				// A rendered file modified outside of Terraform is reported as drift
+				PreConfig: func() {
+					requireNoError(t, os.WriteFile(filepath.Join(destinationDir, "app.conf"), []byte("name = changed\n"), 0644))
+				},
+				Config:             config,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
+
+func TestResourceUtilitiesTemplateDirectorySymlinkedSource(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "templates-v1")
+	sourceLink := filepath.Join(tempDir, "templates")
+	destinationDir := filepath.Join(tempDir, "rendered")
+
+	requireNoError(t, os.MkdirAll(sourceDir, 0755))
+	requireNoError(t, os.WriteFile(filepath.Join(sourceDir, "app.conf.tmpl"), []byte("name = {{ .name }}\n"), 0644))
+	requireNoError(t, os.Symlink(sourceDir, sourceLink))
+
+	// A source_dir that is a link to a directory renders the directory it points to
+	resource.UnitTest(t, resource.TestCase{
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_8_0),
+		},
+		ProtoV6ProviderFactories: TestAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+					resource "utilities_template_directory" "example" {
+						source_dir      = "%s"
+						destination_dir = "%s"
+
+						vars = {
+							name = "web"
+						}
+					}
+				`, sourceLink, destinationDir),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("utilities_template_directory.example", "created_files.#", "1"),
+					resource.TestCheckResourceAttr("utilities_template_directory.example", "created_files.0", filepath.Join(destinationDir, "app.conf")),
+				),
+			},
+		},
+	})
+}